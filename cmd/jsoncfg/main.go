@@ -0,0 +1,377 @@
+// Jsoncfg checks, evaluates and formats jsoncfgo config files.
+//
+// Usage:
+//
+//	jsoncfg validate [-relaxed] [-schema file] file...
+//	jsoncfg eval [-relaxed] file...
+//	jsoncfg get [-relaxed] path file...
+//	jsoncfg explain [-relaxed] [-env prefix] path file...
+//	jsoncfg fmt [-l | -w] file...
+//	jsoncfg diff [-relaxed] [-raw] [-json] [-mask regexp] old new
+//
+// Validate reads and evaluates the config, reporting syntax errors,
+// include cycles and expressions that cannot be evaluated, and checks it
+// against a JSON Schema if one is given. Eval prints the evaluated config
+// as JSON. Get prints the value at a dotted path or JSON Pointer, strings
+// without quotes. Explain prints where the value at the path came from:
+// its position, the expression that produced it and the environment
+// variable overriding it with -env, followed by the _fileobj expressions
+// through which its file was included. Several files are merged in order,
+// as by ReadFiles. Values marked with _secret are printed as ***.
+//
+// Fmt prints the files in canonical form: indented by two spaces, with
+// sorted keys and the numbers as written. Expressions are not evaluated.
+// With -w, it rewrites the files that are not in canonical form; with -l,
+// it lists them instead.
+//
+// Diff prints the changes from the config old to the config new, as by
+// jsoncfgo.WriteDiff, or as JSON with -json, masking secret values, and
+// with -mask the values of the keys that the regexp matches in full. With
+// -raw, the configs are compared as written, without evaluating their
+// expressions.
+//
+// The exit status is 0 on success, 1 if a file is invalid, cannot be read,
+// has no value at the path given to get or explain, or is listed by fmt -l, and 2
+// for a usage error. Like diff(1), diff exits with status 0 if the configs
+// are the same, 1 if they differ and 2 if either cannot be read.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	jsoncfgo "github.com/go-goodies/go_jsoncfg"
+)
+
+const usage = `usage: jsoncfg <command> [flags] [arguments]
+
+commands:
+  validate [-relaxed] [-schema file] file...      check a config
+  eval [-relaxed] file...                         print the evaluated config
+  get [-relaxed] path file...                     print the value at path
+  explain [-relaxed] [-env prefix] path file...   print where the value at path came from
+  fmt [-l | -w] file...                           print or rewrite files in canonical form
+  diff [-relaxed] [-raw] [-json] [-mask regexp] old new
+                                                  print the changes between two configs
+`
+
+var commands = map[string]func(args []string, stdout, stderr io.Writer) int{
+	"validate": validateCmd,
+	"eval":     evalCmd,
+	"get":      getCmd,
+	"explain":  explainCmd,
+	"fmt":      fmtCmd,
+	"diff":     diffCmd,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "jsoncfg: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	return cmd(args[1:], stdout, stderr)
+}
+
+// newFlagSet returns the flag set of the command name, whose arguments
+// are described by argsUsage.
+func newFlagSet(name, argsUsage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: jsoncfg %s [flags] %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of args and returns the remaining arguments,
+// which must number at least minArgs. If the command must stop instead,
+// it returns false and the exit status.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs int) ([]string, int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, 0, false
+		}
+		return nil, 2, false
+	}
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return nil, 2, false
+	}
+	return fs.Args(), 0, true
+}
+
+// readConfig reads and evaluates the config files, merging them if there
+// are several.
+func readConfig(c *jsoncfgo.ConfigParser, files []string) (jsoncfgo.Obj, error) {
+	var m map[string]interface{}
+	var err error
+	if len(files) == 1 {
+		m, err = c.ReadFile(files[0])
+	} else {
+		m, err = c.ReadFiles(files...)
+	}
+	if err != nil {
+		return jsoncfgo.Obj{}, err
+	}
+	return jsoncfgo.NewObj(m), nil
+}
+
+// printErr prints err to stderr, one line per error of a MultiError.
+func printErr(stderr io.Writer, err error) {
+	var merr jsoncfgo.MultiError
+	if errors.As(err, &merr) {
+		for _, e := range merr {
+			fmt.Fprintln(stderr, e)
+		}
+		return
+	}
+	fmt.Fprintln(stderr, err)
+}
+
+func validateCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate", "file...", stderr)
+	relaxed := fs.Bool("relaxed", false, "accept comments, trailing commas and unquoted keys")
+	schemaFile := fs.String("schema", "", "check the config against the JSON Schema `file`")
+	files, status, ok := parseFlags(fs, args, 1)
+	if !ok {
+		return status
+	}
+	var schema *jsoncfgo.Schema
+	if *schemaFile != "" {
+		var err error
+		if schema, err = jsoncfgo.LoadSchema(*schemaFile); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	c := jsoncfgo.ConfigParser{Relaxed: *relaxed}
+	if _, err := readConfig(&c, files); err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	if schema != nil {
+		if err := c.ValidateSchema(schema); err != nil {
+			printErr(stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
+
+func evalCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("eval", "file...", stderr)
+	relaxed := fs.Bool("relaxed", false, "accept comments, trailing commas and unquoted keys")
+	files, status, ok := parseFlags(fs, args, 1)
+	if !ok {
+		return status
+	}
+	c := jsoncfgo.ConfigParser{Relaxed: *relaxed}
+	obj, err := readConfig(&c, files)
+	if err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	out, err := marshal(obj.Map())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	stdout.Write(out)
+	return 0
+}
+
+func getCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("get", "path file...", stderr)
+	relaxed := fs.Bool("relaxed", false, "accept comments, trailing commas and unquoted keys")
+	rest, status, ok := parseFlags(fs, args, 2)
+	if !ok {
+		return status
+	}
+	path, files := rest[0], rest[1:]
+	c := jsoncfgo.ConfigParser{Relaxed: *relaxed}
+	obj, err := readConfig(&c, files)
+	if err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	p := obj.At(path)
+	if !p.Exists() {
+		fmt.Fprintf(stderr, "jsoncfg: no value at %q\n", path)
+		return 1
+	}
+	switch v := p.Value().(type) {
+	case string, jsoncfgo.Secret:
+		fmt.Fprintln(stdout, v)
+		return 0
+	}
+	out, err := marshal(p.Value())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	stdout.Write(out)
+	return 0
+}
+
+func explainCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("explain", "path file...", stderr)
+	relaxed := fs.Bool("relaxed", false, "accept comments, trailing commas and unquoted keys")
+	envPrefix := fs.String("env", "", "let environment variables starting with `prefix` override values")
+	rest, status, ok := parseFlags(fs, args, 2)
+	if !ok {
+		return status
+	}
+	path, files := rest[0], rest[1:]
+	c := jsoncfgo.ConfigParser{Relaxed: *relaxed, EnvPrefix: *envPrefix}
+	if _, err := readConfig(&c, files); err != nil {
+		printErr(stderr, err)
+		return 1
+	}
+	o, ok := c.Origin(path)
+	if !ok {
+		fmt.Fprintf(stderr, "jsoncfg: no value at %q\n", path)
+		return 1
+	}
+	fmt.Fprintln(stdout, o)
+	for inc := o.Included; inc != nil; inc = inc.Included {
+		fmt.Fprintf(stdout, "\tincluded at %v\n", inc)
+	}
+	return 0
+}
+
+func fmtCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("fmt", "file...", stderr)
+	list := fs.Bool("l", false, "list the files not in canonical form, exiting with status 1 if any")
+	write := fs.Bool("w", false, "rewrite the files not in canonical form")
+	files, status, ok := parseFlags(fs, args, 1)
+	if !ok {
+		return status
+	}
+	if *list && *write {
+		fmt.Fprintln(stderr, "jsoncfg: fmt: -l and -w are mutually exclusive")
+		return 2
+	}
+	status = 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+		out, err := canonical(data)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			status = 1
+			continue
+		}
+		switch {
+		case *list:
+			if !bytes.Equal(data, out) {
+				fmt.Fprintln(stdout, file)
+				status = 1
+			}
+		case *write:
+			if !bytes.Equal(data, out) {
+				if err := os.WriteFile(file, out, 0666); err != nil {
+					fmt.Fprintln(stderr, err)
+					status = 1
+				}
+			}
+		default:
+			stdout.Write(out)
+		}
+	}
+	return status
+}
+
+func diffCmd(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("diff", "old new", stderr)
+	relaxed := fs.Bool("relaxed", false, "accept comments, trailing commas and unquoted keys")
+	raw := fs.Bool("raw", false, "compare the configs as written, without evaluating expressions")
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	mask := fs.String("mask", "", "mask the values of the keys matching `regexp` in full")
+	files, status, ok := parseFlags(fs, args, 2)
+	if !ok {
+		return status
+	}
+	if len(files) != 2 {
+		fs.Usage()
+		return 2
+	}
+	var opts []jsoncfgo.DiffOption
+	if *mask != "" {
+		re, err := regexp.Compile(*mask)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsoncfg: bad -mask: %v\n", err)
+			return 2
+		}
+		opts = append(opts, jsoncfgo.MaskKeys(re))
+	}
+	var objs [2]jsoncfgo.Obj
+	for i, file := range files {
+		c := jsoncfgo.ConfigParser{Relaxed: *relaxed, Raw: *raw}
+		obj, err := c.ReadFile(file)
+		if err != nil {
+			printErr(stderr, err)
+			return 2
+		}
+		objs[i] = jsoncfgo.NewObj(obj)
+	}
+	changes := jsoncfgo.Diff(objs[0], objs[1])
+	write := jsoncfgo.WriteDiff
+	if *asJSON {
+		write = jsoncfgo.WriteDiffJSON
+	}
+	if err := write(stdout, changes, opts...); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// canonical returns the JSON document data in canonical form.
+func canonical(data []byte) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the top-level value")
+	}
+	return marshal(v)
+}
+
+// marshal returns v as indented JSON followed by a newline, without
+// escaping the characters special to HTML.
+func marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
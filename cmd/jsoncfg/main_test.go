@@ -0,0 +1,151 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	t.Setenv("TESTAPP_NAME", "payments")
+	for _, tt := range []struct {
+		args   []string
+		status int
+		stdout string
+		stderr string // a substring of the standard error
+	}{
+		{[]string{"validate", "testdata/app.json"}, 0, "", ""},
+		{[]string{"validate", "-schema", "testdata/schema.json", "testdata/app.json"}, 0, "", ""},
+		{[]string{"validate", "-schema", "testdata/schema.json", "testdata/app.json", "testdata/prod.json"}, 1, "",
+			`testdata/prod.json:2:22: Config value at "/server/port" must be at most 65535`},
+		{[]string{"validate", "testdata/cycle.json"}, 1, "", "include cycle detected"},
+		{[]string{"validate", "testdata/messy.json", "testdata/missing.json"}, 1, "", "missing.json"},
+		{[]string{"validate"}, 2, "", "usage: jsoncfg validate"},
+		{[]string{"eval", "testdata/app.json", "testdata/prod.json"}, 0, `{
+  "db": {
+    "pool": 4,
+    "user": "app"
+  },
+  "name": "billing",
+  "server": {
+    "host": "localhost",
+    "port": 70000
+  },
+  "tags": [
+    "a",
+    "b"
+  ]
+}
+`, ""},
+		{[]string{"get", "db.user", "testdata/app.json"}, 0, "app\n", ""},
+		{[]string{"get", "/tags", "testdata/app.json"}, 0, "[\n  \"a\",\n  \"b\"\n]\n", ""},
+		{[]string{"get", "server.port", "testdata/app.json", "testdata/prod.json"}, 0, "70000\n", ""},
+		{[]string{"eval", "testdata/secret.json"}, 0, "{\n  \"password\": \"***\",\n  \"user\": \"app\"\n}\n", ""},
+		{[]string{"get", "password", "testdata/secret.json"}, 0, "***\n", ""},
+		{[]string{"diff", "testdata/secret.json", "testdata/db.json"}, 1, `- /password: "***"
++ /pool: 4
+`, ""},
+		{[]string{"get", "server.name", "testdata/app.json"}, 1, "", `no value at "server.name"`},
+		{[]string{"get", "server.port"}, 2, "", "usage: jsoncfg get"},
+		{[]string{"explain", "db.user", "testdata/app.json"}, 0,
+			"testdata/db.json:2:11\n\tincluded at testdata/app.json:4:9 (_fileobj testdata/db.json)\n", ""},
+		{[]string{"explain", "/server/port", "testdata/app.json", "testdata/prod.json"}, 0, "testdata/prod.json:2:22\n", ""},
+		{[]string{"explain", "-env", "TESTAPP", "name", "testdata/app.json"}, 0,
+			"testdata/app.json:2:11 (overridden by TESTAPP_NAME)\n", ""},
+		{[]string{"explain", "db.host", "testdata/app.json"}, 1, "", `no value at "db.host"`},
+		{[]string{"fmt", "testdata/messy.json"}, 0, `{
+  "a": 1e3,
+  "b": [
+    1,
+    2.50,
+    {
+      "x": true,
+      "y": "<x>"
+    }
+  ]
+}
+`, ""},
+		{[]string{"fmt", "-l", "testdata/messy.json", "testdata/tidy.json"}, 1, "testdata/messy.json\n", ""},
+		{[]string{"fmt", "-l", "testdata/tidy.json"}, 0, "", ""},
+		{[]string{"fmt", "-l", "-w", "testdata/tidy.json"}, 2, "", "mutually exclusive"},
+		{[]string{"diff", "testdata/app.json", "testdata/next.json"}, 1, `+ /db/password: "hunter2"
+~ /db/pool: 4 -> 8
+~ /server/port: 8080 -> 9090
+`, ""},
+		{[]string{"diff", "-mask", "(?i)password", "testdata/app.json", "testdata/next.json"}, 1, `+ /db/password: "***"
+~ /db/pool: 4 -> 8
+~ /server/port: 8080 -> 9090
+`, ""},
+		{[]string{"diff", "-mask", "(", "testdata/app.json", "testdata/next.json"}, 2, "", "bad -mask"},
+		{[]string{"diff", "-raw", "-mask", "password", "testdata/app.json", "testdata/next.json"}, 1,
+			`~ /db: ["_fileobj","testdata/db.json"] -> {"password":"***","pool":8,"user":"app"} (array -> object)
+~ /server/port: 8080 -> 9090
+`, ""},
+		{[]string{"diff", "-json", "testdata/app.json", "testdata/prod.json"}, 1, `[
+  {
+    "kind": "removed",
+    "path": "/db",
+    "old": {
+      "pool": 4,
+      "user": "app"
+    }
+  },
+  {
+    "kind": "removed",
+    "path": "/name",
+    "old": "billing"
+  },
+  {
+    "kind": "removed",
+    "path": "/server/host",
+    "old": "localhost"
+  },
+  {
+    "kind": "changed",
+    "path": "/server/port",
+    "old": 8080,
+    "new": 70000
+  },
+  {
+    "kind": "removed",
+    "path": "/tags",
+    "old": [
+      "a",
+      "b"
+    ]
+  }
+]
+`, ""},
+		{[]string{"diff", "-json", "testdata/app.json", "testdata/app.json"}, 0, "[]\n", ""},
+		{[]string{"diff", "testdata/app.json", "testdata/missing.json"}, 2, "", "missing.json"},
+		{[]string{"diff", "testdata/app.json"}, 2, "", "usage: jsoncfg diff"},
+		{[]string{"lint"}, 2, "", `unknown command "lint"`},
+		{nil, 2, "", "usage: jsoncfg <command>"},
+	} {
+		var stdout, stderr strings.Builder
+		status := run(tt.args, &stdout, &stderr)
+		if status != tt.status || stdout.String() != tt.stdout || !strings.Contains(stderr.String(), tt.stderr) {
+			t.Errorf("jsoncfg %s = %d\nstdout: %s\nstderr: %s\nwant %d\nstdout: %s\nstderr containing: %s",
+				strings.Join(tt.args, " "), status, stdout.String(), stderr.String(), tt.status, tt.stdout, tt.stderr)
+		}
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	data, err := os.ReadFile("testdata/messy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "messy.json")
+	if err := os.WriteFile(file, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	if status := run([]string{"fmt", "-w", file}, &stdout, &stderr); status != 0 {
+		t.Fatalf("fmt -w = %d: %s", status, stderr.String())
+	}
+	if status := run([]string{"fmt", "-l", file}, &stdout, &stderr); status != 0 || stdout.Len() != 0 {
+		t.Errorf("fmt -l after fmt -w = %d, %q; want 0 and no files", status, stdout.String())
+	}
+}
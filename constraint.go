@@ -0,0 +1,140 @@
+package jsoncfgo
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Constraint checks a config value of type T, returning an error that
+// describes the violation, such as "must be at most 65535", or nil.
+// Constraints are passed to Check, Get, GetOr and MustGet.
+type Constraint[T any] func(v T) error
+
+// OneOf requires the value to be one of vals.
+func OneOf[T comparable](vals ...T) Constraint[T] {
+	return func(v T) error {
+		for _, ok := range vals {
+			if v == ok {
+				return nil
+			}
+		}
+		strs := make([]string, len(vals))
+		for i, ok := range vals {
+			strs[i] = formatValue(ok)
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(strs, ", "))
+	}
+}
+
+// Min requires the value to be at least min.
+func Min[T cmp.Ordered](min T) Constraint[T] {
+	return func(v T) error {
+		if v < min {
+			return fmt.Errorf("must be at least %s", formatValue(min))
+		}
+		return nil
+	}
+}
+
+// Max requires the value to be at most max.
+func Max[T cmp.Ordered](max T) Constraint[T] {
+	return func(v T) error {
+		if v > max {
+			return fmt.Errorf("must be at most %s", formatValue(max))
+		}
+		return nil
+	}
+}
+
+// Pattern requires the value to match the regular expression expr, which
+// is not anchored unless it says so. It panics if expr does not compile.
+func Pattern(expr string) Constraint[string] {
+	re := regexp.MustCompile(expr)
+	return func(v string) error {
+		if !re.MatchString(v) {
+			return fmt.Errorf("must match %q", expr)
+		}
+		return nil
+	}
+}
+
+// Sized is the set of types with a length, to which NonEmpty, MinLen and
+// MaxLen apply: strings and the list and map types of Gettable.
+type Sized interface {
+	string | []string | []int64 | []float64 | []bool | []time.Duration |
+		[]Obj | []netip.Addr | []netip.Prefix |
+		map[string]string | map[string]int64 | map[string]Obj
+}
+
+// NonEmpty requires a string, list or map value to have a length.
+func NonEmpty[T Sized]() Constraint[T] {
+	return func(v T) error {
+		if len(v) == 0 {
+			return errors.New("must not be empty")
+		}
+		return nil
+	}
+}
+
+// MinLen requires a string, list or map value to have a length of at
+// least n. The length of a string is its number of bytes.
+func MinLen[T Sized](n int) Constraint[T] {
+	return func(v T) error {
+		if len(v) < n {
+			return fmt.Errorf("must have a length of at least %d", n)
+		}
+		return nil
+	}
+}
+
+// MaxLen requires a string, list or map value to have a length of at most
+// n. The length of a string is its number of bytes.
+func MaxLen[T Sized](n int) Constraint[T] {
+	return func(v T) error {
+		if len(v) > n {
+			return fmt.Errorf("must have a length of at most %d", n)
+		}
+		return nil
+	}
+}
+
+// Check checks the value v of key against the constraints cs, and records
+// each violation as an error of jc, to be reported by Validate. It returns
+// v, so that it can wrap an accessor:
+//
+//	port := jsoncfgo.Check(cfg, "port", cfg.RequiredInt("port"), jsoncfgo.Min(1), jsoncfgo.Max(65535))
+//
+// Check does not know whether v was read from the config, so it should not
+// be used on a missing or bad value; Get, GetOr and MustGet only check the
+// values they read successfully.
+func Check[T any](jc Obj, key string, v T, cs ...Constraint[T]) T {
+	for _, err := range checkConstraints(jc, key, v, cs) {
+		jc.appendError(err)
+	}
+	return v
+}
+
+func checkConstraints[T any](jc Obj, key string, v T, cs []Constraint[T]) []error {
+	var errs []error
+	for _, c := range cs {
+		if err := c(v); err != nil {
+			errs = append(errs, jc.constraintError(key, v, err))
+		}
+	}
+	return errs
+}
+
+// formatValue formats a config value for an error message, quoting
+// strings.
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,133 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConstraints(t *testing.T) {
+	obj, err := ReadFile("testdata/constraint.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Get(obj, "log_level", OneOf("debug", "info", "warn"))
+	GetOr(obj, "port", 8080, Min(1), Max(65535))
+	Get(obj, "name", Pattern("^[a-z][a-z0-9-]*$"))
+	Get(obj, "tags", NonEmpty[[]string]())
+	Get(obj, "ratio", Min(0.0), Max(1.0))
+	Get(obj, "timeout", Max(time.Minute))
+	Get(obj, "hosts", MinLen[[]string](1), MaxLen[[]string](2))
+	Check(obj, "workers", obj.RequiredInt("workers"), OneOf(1, 2, 8))
+	GetOr(obj, "missing", 0, Min(1))
+	Get(obj, "log_level", MaxLen[string](3), NonEmpty[string]())
+	Get(obj, "port", Max(10), OneOf(1))
+
+	var merr MultiError
+	if err := obj.Validate(); !errors.As(err, &merr) {
+		t.Fatalf("Validate = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Config key "log_level" value "trace" must be one of "debug", "info", "warn"`,
+		`Config key "port" value 70000 must be at most 65535`,
+		`Config key "name" value "Web_1" must match "^[a-z][a-z0-9-]*$"`,
+		`Config key "tags" value [] must not be empty`,
+		`Config key "timeout" value 1m30s must be at most 1m0s`,
+		`Config key "hosts" value [a b c] must have a length of at most 2`,
+		`Config key "workers" value 4 must be one of 1, 2, 8`,
+		`Config key "log_level" value "trace" must have a length of at most 3`,
+		`Config key "port" value 70000 must be at most 10`,
+		`Config key "port" value 70000 must be one of 1`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+		if !errors.Is(merr[i], ErrConstraint) {
+			t.Errorf("error %d is not ErrConstraint", i)
+		}
+	}
+}
+
+func TestConstraintsSkipBadValues(t *testing.T) {
+	obj := NewObj(map[string]interface{}{"port": "http"})
+	if g := Get(obj, "port", Min(1)); g != 0 {
+		t.Errorf("port = %d; want 0", g)
+	}
+	Get(obj, "missing", Min(1))
+	errs := obj.errorList()
+	if len(errs) != 2 || !errors.Is(errs[0], ErrWrongType) || !errors.Is(errs[1], ErrMissingKey) {
+		t.Errorf("errors = %v; want a type error and a missing key", errs)
+	}
+
+	defer func() {
+		if err, ok := recover().(error); !ok || !errors.Is(err, ErrConstraint) {
+			t.Errorf("MustGet panicked with %v; want a constraint error", err)
+		}
+	}()
+	MustGet(NewObj(map[string]interface{}{"n": 5}), "n", Max(4))
+}
+
+func TestDecodeConstraints(t *testing.T) {
+	var cfg struct {
+		Level   string        `jsoncfg:"log_level" enum:"debug,info,warn"`
+		Port    int           `jsoncfg:"port" min:"1" max:"65535"`
+		Name    string        `jsoncfg:"name" pattern:"^[a-z]"`
+		Ratio   float64       `jsoncfg:"ratio" max:"0.25"`
+		Timeout time.Duration `jsoncfg:"timeout" min:"1s" max:"2m"`
+		Workers uint          `jsoncfg:"workers" enum:"1,2,4"`
+		Missing int           `jsoncfg:"missing" min:"5"`
+	}
+	obj, err := ReadFile("testdata/constraint.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	var merr MultiError
+	if err := obj.Validate(); !errors.As(err, &merr) {
+		t.Fatalf("Validate = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Config key "log_level" value "trace" must be one of "debug", "info", "warn"`,
+		`Config key "port" value 70000 must be at most 65535`,
+		`Config key "name" value "Web_1" must match "^[a-z]"`,
+		`Config key "ratio" value 0.5 must be at most 0.25`,
+		`Unknown key "hosts"`,
+		`Unknown key "tags"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+
+	for _, bad := range []interface{}{
+		&struct {
+			N int `min:"one"`
+		}{},
+		&struct {
+			S string `max:"3"`
+		}{},
+		&struct {
+			N int `pattern:"x"`
+		}{},
+		&struct {
+			S string `pattern:"("`
+		}{},
+		&struct {
+			L []string `enum:"a,b"`
+		}{},
+	} {
+		if err := (Obj{}).Decode(bad); err == nil {
+			t.Errorf("Decode(%T) succeeded; want a malformed constraint error", bad)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package jsoncfgo
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	objType      = reflect.TypeOf(Obj{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Decode fills the struct pointed to by v with the values of jc.
+//
+// Each exported field is read from the key named by its jsoncfg tag, or
+// from the field name if it has none. A tag of "-" skips the field. The
+// "required" option makes a missing key an error; otherwise a missing key
+// sets the field from its default tag, or leaves it untouched if there is
+// none, so fields may also be preset before calling Decode:
+//
+//	type Config struct {
+//		Host  string   `jsoncfg:"host,required"`
+//		Port  int      `jsoncfg:"port" default:"8080"`
+//		Tags  []string `jsoncfg:"tags" default:"web,public"`
+//		DB    DBConfig `jsoncfg:"db,required"`
+//		Extra Obj      `jsoncfg:"extra"`
+//	}
+//
+// Supported field types are string, bool, int, int64, uint, float64,
+// time.Duration, time.Time, Obj, structs or pointers to structs, which are
+// decoded from nested objects, and slices of string, bool, int64, float64,
+// time.Duration, Obj and structs. The fields of embedded structs without a
+// tag are read from jc itself. List defaults are comma separated.
+//
+// Durations are read as by Duration. Times are read as RFC 3339, or with
+// the layout given by a layout tag. The "size" option reads an int or
+// int64 field as a byte size, as by Size:
+//
+//	Timeout time.Duration `jsoncfg:"timeout" default:"30s"`
+//	Day     time.Time     `jsoncfg:"day" layout:"2006-01-02"`
+//	MaxBody int64         `jsoncfg:"max_body,size" default:"1MiB"`
+//
+// The enum, min, max and pattern tags constrain the values read, like the
+// constraints OneOf, Min, Max and Pattern. Enum values are comma separated;
+// min and max apply to numbers and durations, and pattern to strings:
+//
+//	Level string `jsoncfg:"log_level" enum:"debug,info,warn" default:"info"`
+//	Port  int    `jsoncfg:"port" min:"1" max:"65535"`
+//	Name  string `jsoncfg:"name" pattern:"^[a-z][a-z0-9-]*$"`
+//
+// A description tag is ignored by Decode; GenerateSchema uses it to
+// document the key.
+//
+// A missing optional nested object leaves a pointer field untouched, and
+// sets the fields of a struct field from their defaults without requiring
+// its required keys, which only apply when the object is present.
+//
+// Decode reads keys with the RequiredT and OptionalT methods, so missing
+// keys and type errors are accumulated on jc and the nested objects Decode
+// descends into, and reported along with their unknown keys by ValidateAll.
+// Decode itself only returns an error if v is not a pointer to a struct
+// or a field has an unsupported type or malformed default or constraint.
+func (jc Obj) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("jsoncfgo: Decode requires a non-nil pointer to a struct, not %T", v)
+	}
+	return jc.decodeStruct(rv.Elem())
+}
+
+// fieldTag holds the decoding options of a struct field.
+type fieldTag struct {
+	key      string
+	required bool
+	size     bool
+	layout   string
+	def      *string
+	enum     []string
+	min, max *string
+	pattern  *string
+}
+
+// parseFieldTag returns the decoding options of f, and false if f must
+// be skipped.
+func parseFieldTag(f reflect.StructField) (fieldTag, bool) {
+	tag := fieldTag{key: f.Name}
+	if s, ok := f.Tag.Lookup("jsoncfg"); ok {
+		if s == "-" {
+			return tag, false
+		}
+		parts := strings.Split(s, ",")
+		if parts[0] != "" {
+			tag.key = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "required":
+				tag.required = true
+			case "size":
+				tag.size = true
+			}
+		}
+	}
+	if def, ok := f.Tag.Lookup("default"); ok {
+		tag.def = &def
+	}
+	tag.layout = time.RFC3339
+	if layout, ok := f.Tag.Lookup("layout"); ok {
+		tag.layout = layout
+	}
+	if enum, ok := f.Tag.Lookup("enum"); ok {
+		tag.enum = splitDefault(enum)
+	}
+	if min, ok := f.Tag.Lookup("min"); ok {
+		tag.min = &min
+	}
+	if max, ok := f.Tag.Lookup("max"); ok {
+		tag.max = &max
+	}
+	if pattern, ok := f.Tag.Lookup("pattern"); ok {
+		tag.pattern = &pattern
+	}
+	return tag, true
+}
+
+func (jc Obj) decodeStruct(sv reflect.Value) error {
+	st := sv.Type()
+	for i := 0; i < st.NumField(); i++ {
+		f := st.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if _, tagged := f.Tag.Lookup("jsoncfg"); !tagged {
+				if err := jc.decodeStruct(sv.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		tag, ok := parseFieldTag(f)
+		if !ok {
+			continue
+		}
+		nerrs := len(jc.errorList())
+		if err := jc.decodeField(sv.Field(i), tag); err != nil {
+			return fmt.Errorf("jsoncfgo: field %s.%s: %v", st.Name(), f.Name, err)
+		}
+		// Only check a value that was read, and read successfully.
+		_, present := jc.m[tag.key]
+		check := present && len(jc.errorList()) == nerrs
+		if err := jc.checkField(sv.Field(i), tag, check); err != nil {
+			return fmt.Errorf("jsoncfgo: field %s.%s: %v", st.Name(), f.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkField records the violations of the constraint tags of the field
+// fv if check is true. It returns an error if a constraint tag is
+// malformed or does not apply to the type of fv, whether or not check is
+// true.
+func (jc Obj) checkField(fv reflect.Value, tag fieldTag, check bool) error {
+	var errs []error
+	if tag.enum != nil {
+		if k := fv.Kind(); k == reflect.Struct || k == reflect.Slice || k == reflect.Map || k == reflect.Ptr {
+			return fmt.Errorf("enum tag on unsupported type %v", fv.Type())
+		}
+		vals := append([]string(nil), tag.enum...)
+		if fv.Kind() == reflect.String {
+			for i, v := range vals {
+				vals[i] = strconv.Quote(v)
+			}
+		}
+		if !slices.Contains(tag.enum, fmt.Sprint(fv.Interface())) {
+			errs = append(errs, fmt.Errorf("must be one of %s", strings.Join(vals, ", ")))
+		}
+	}
+	for _, b := range []struct {
+		s   *string
+		max bool
+	}{{tag.min, false}, {tag.max, true}} {
+		if b.s == nil {
+			continue
+		}
+		violation, err := checkBound(fv, *b.s, b.max)
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			errs = append(errs, violation)
+		}
+	}
+	if tag.pattern != nil {
+		if fv.Kind() != reflect.String {
+			return fmt.Errorf("pattern tag on unsupported type %v", fv.Type())
+		}
+		re, err := regexp.Compile(*tag.pattern)
+		if err != nil {
+			return fmt.Errorf("bad pattern %q: %v", *tag.pattern, err)
+		}
+		if !re.MatchString(fv.String()) {
+			errs = append(errs, fmt.Errorf("must match %q", *tag.pattern))
+		}
+	}
+	if check {
+		for _, err := range errs {
+			jc.appendError(jc.constraintError(tag.key, fv.Interface(), err))
+		}
+	}
+	return nil
+}
+
+// checkBound checks the number or duration fv against the bound s, a
+// maximum if max is true and a minimum otherwise, and returns the
+// violation, if any. It returns an error if s is malformed or does not
+// apply to fv.
+func checkBound(fv reflect.Value, s string, max bool) (violation, err error) {
+	name := "min"
+	if max {
+		name = "max"
+	}
+	switch {
+	case fv.Type() == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(s); err == nil {
+			return bound(time.Duration(fv.Int()), d, max), nil
+		}
+	case fv.Kind() == reflect.Int || fv.Kind() == reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(s, 10, 64); err == nil {
+			return bound(fv.Int(), n, max), nil
+		}
+	case fv.Kind() == reflect.Uint:
+		var n uint64
+		if n, err = strconv.ParseUint(s, 10, 64); err == nil {
+			return bound(fv.Uint(), n, max), nil
+		}
+	case fv.Kind() == reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			return bound(fv.Float(), f, max), nil
+		}
+	default:
+		return nil, fmt.Errorf("%s tag on unsupported type %v", name, fv.Type())
+	}
+	return nil, fmt.Errorf("bad %s %q: %v", name, s, err)
+}
+
+func bound[T cmp.Ordered](v, b T, max bool) error {
+	if max {
+		return Max(b)(v)
+	}
+	return Min(b)(v)
+}
+
+func (jc Obj) decodeField(fv reflect.Value, tag fieldTag) error {
+	key := tag.key
+	ft := fv.Type()
+	if ft == objType {
+		if tag.required {
+			fv.Set(reflect.ValueOf(jc.RequiredObject(key)))
+		} else {
+			fv.Set(reflect.ValueOf(jc.OptionalObject(key)))
+		}
+		return nil
+	}
+	switch {
+	case ft == durationType:
+		var def *time.Duration
+		if !tag.required {
+			d := time.Duration(fv.Int())
+			if tag.def != nil {
+				var err error
+				if d, err = time.ParseDuration(*tag.def); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &d
+		}
+		fv.SetInt(int64(jc.duration(key, def)))
+		return nil
+	case ft == timeType:
+		var def *time.Time
+		if !tag.required {
+			t := fv.Interface().(time.Time)
+			if tag.def != nil {
+				var err error
+				if t, err = time.Parse(tag.layout, *tag.def); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &t
+		}
+		fv.Set(reflect.ValueOf(jc.time(key, tag.layout, def)))
+		return nil
+	case tag.size:
+		return jc.decodeSize(fv, tag)
+	}
+	switch ft.Kind() {
+	case reflect.String:
+		var def *string
+		if !tag.required {
+			s := fv.String()
+			if tag.def != nil {
+				s = *tag.def
+			}
+			def = &s
+		}
+		fv.SetString(jc.string(key, def))
+	case reflect.Bool:
+		var def *bool
+		if !tag.required {
+			b := fv.Bool()
+			if tag.def != nil {
+				var err error
+				if b, err = strconv.ParseBool(*tag.def); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &b
+		}
+		fv.SetBool(jc.bool(key, def))
+	case reflect.Int:
+		var def *int
+		if !tag.required {
+			n := int(fv.Int())
+			if tag.def != nil {
+				var err error
+				if n, err = strconv.Atoi(*tag.def); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &n
+		}
+		fv.SetInt(int64(jc.int(key, def)))
+	case reflect.Int64:
+		var def *int64
+		if !tag.required {
+			n := fv.Int()
+			if tag.def != nil {
+				var err error
+				if n, err = strconv.ParseInt(*tag.def, 10, 64); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &n
+		}
+		fv.SetInt(jc.int64(key, def))
+	case reflect.Float64:
+		var def *float64
+		if !tag.required {
+			f := fv.Float()
+			if tag.def != nil {
+				var err error
+				if f, err = strconv.ParseFloat(*tag.def, 64); err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+			}
+			def = &f
+		}
+		fv.SetFloat(jc.float(key, def))
+	case reflect.Uint:
+		var def *uint
+		if !tag.required {
+			n := uint(fv.Uint())
+			if tag.def != nil {
+				u, err := strconv.ParseUint(*tag.def, 10, 0)
+				if err != nil {
+					return fmt.Errorf("bad default %q: %v", *tag.def, err)
+				}
+				n = uint(u)
+			}
+			def = &n
+		}
+		fv.SetUint(uint64(jc.uint(key, def)))
+	case reflect.Slice:
+		return jc.decodeList(fv, tag)
+	case reflect.Struct:
+		return jc.decodeObject(fv, tag)
+	case reflect.Ptr:
+		if ft.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported type %v", ft)
+		}
+		if _, ok := jc.m[key]; !ok && !tag.required {
+			return nil
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.New(ft.Elem()))
+		}
+		return jc.decodeObject(fv.Elem(), tag)
+	default:
+		return fmt.Errorf("unsupported type %v", ft)
+	}
+	return nil
+}
+
+// decodeSize decodes the byte size at tag.key into the int or int64 fv.
+func (jc Obj) decodeSize(fv reflect.Value, tag fieldTag) error {
+	if k := fv.Kind(); k != reflect.Int && k != reflect.Int64 {
+		return fmt.Errorf("size option on unsupported type %v", fv.Type())
+	}
+	var def *int64
+	if !tag.required {
+		n := fv.Int()
+		if tag.def != nil {
+			var err error
+			if n, err = ParseSize(*tag.def); err != nil {
+				return fmt.Errorf("bad default %q: %v", *tag.def, err)
+			}
+		}
+		def = &n
+	}
+	n := jc.size(tag.key, def)
+	if fv.OverflowInt(n) {
+		jc.appendError(jc.badValue(tag.key, -1, jc.m[tag.key], fv.Type().String(), errRange))
+		return nil
+	}
+	fv.SetInt(n)
+	return nil
+}
+
+func (jc Obj) decodeList(fv reflect.Value, tag fieldTag) error {
+	_, present := jc.m[tag.key]
+	et := fv.Type().Elem()
+	var l interface{}
+	var parse func(string) (interface{}, error)
+	switch {
+	case et == durationType:
+		l = jc.requiredDurationList(tag.key, tag.required)
+		parse = func(s string) (interface{}, error) { return time.ParseDuration(s) }
+	case et == objType:
+		l = jc.requiredObjectList(tag.key, tag.required)
+	case et.Kind() == reflect.String:
+		l = jc.requiredList(tag.key, tag.required)
+		parse = func(s string) (interface{}, error) { return s, nil }
+	case et.Kind() == reflect.Int64:
+		l = jc.requiredIntList(tag.key, tag.required)
+		parse = func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) }
+	case et.Kind() == reflect.Float64:
+		l = jc.requiredFloatList(tag.key, tag.required)
+		parse = func(s string) (interface{}, error) { return strconv.ParseFloat(s, 64) }
+	case et.Kind() == reflect.Bool:
+		l = jc.requiredBoolList(tag.key, tag.required)
+		parse = func(s string) (interface{}, error) { return strconv.ParseBool(s) }
+	case et.Kind() == reflect.Struct && et != timeType:
+		return jc.decodeStructList(fv, tag)
+	default:
+		return fmt.Errorf("unsupported type %v", fv.Type())
+	}
+	lv := reflect.ValueOf(l)
+	if !present && tag.def != nil && parse != nil {
+		lv = reflect.MakeSlice(lv.Type(), 0, 0)
+		for _, s := range splitDefault(*tag.def) {
+			v, err := parse(s)
+			if err != nil {
+				return fmt.Errorf("bad default %q: %v", *tag.def, err)
+			}
+			lv = reflect.Append(lv, reflect.ValueOf(v))
+		}
+	}
+	if !lv.IsNil() || present {
+		fv.Set(lv.Convert(fv.Type()))
+	}
+	return nil
+}
+
+// decodeStructList decodes the list of objects at tag.key into the slice
+// of structs fv.
+func (jc Obj) decodeStructList(fv reflect.Value, tag fieldTag) error {
+	_, present := jc.m[tag.key]
+	ol := jc.requiredObjectList(tag.key, tag.required)
+	if ol == nil {
+		if present {
+			fv.Set(reflect.Zero(fv.Type()))
+		}
+		return nil
+	}
+	sl := reflect.MakeSlice(fv.Type(), len(ol), len(ol))
+	for i, o := range ol {
+		if err := o.decodeStruct(sl.Index(i)); err != nil {
+			return err
+		}
+	}
+	fv.Set(sl)
+	return nil
+}
+
+// splitDefault splits a comma separated list default, trimming spaces.
+func splitDefault(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return []string{}
+	}
+	parts := strings.Split(s, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+// decodeObject decodes the nested object at tag.key into the struct sv.
+// A missing optional object is decoded from an empty object of its own,
+// so that the fields get their defaults but the errors, such as missing
+// required keys, are dropped.
+func (jc Obj) decodeObject(sv reflect.Value, tag fieldTag) error {
+	if tag.required {
+		return jc.RequiredObject(tag.key).decodeStruct(sv)
+	}
+	if _, ok := jc.m[tag.key]; !ok {
+		jc.noteKnownKey(tag.key)
+		return NewObj(nil).decodeStruct(sv)
+	}
+	return jc.OptionalObject(tag.key).decodeStruct(sv)
+}
@@ -0,0 +1,229 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dbConfig struct {
+	User string `jsoncfg:"user,required"`
+	Pool int    `jsoncfg:"pool" default:"4"`
+}
+
+type commonConfig struct {
+	Name string
+}
+
+type decodeConfig struct {
+	commonConfig
+	Host    string    `jsoncfg:"host,required"`
+	Port    int       `jsoncfg:"port" default:"8080"`
+	Timeout int64     `jsoncfg:"timeout" default:"30"`
+	Workers uint      `jsoncfg:"workers"`
+	Verbose bool      `jsoncfg:"verbose"`
+	Tags    []string  `jsoncfg:"tags"`
+	Zones   []string  `jsoncfg:"zones" default:"us, eu"`
+	IDs     []int64   `jsoncfg:"ids"`
+	DB      dbConfig  `jsoncfg:"db,required"`
+	Replica *dbConfig `jsoncfg:"replica"`
+	Backup  *dbConfig `jsoncfg:"backup"`
+	Extra   Obj       `jsoncfg:"extra"`
+	Skipped string    `jsoncfg:"-"`
+	ignored string
+}
+
+func TestDecode(t *testing.T) {
+	obj, err := ReadFile("testdata/decode.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := decodeConfig{Workers: 2, Skipped: "keep"}
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Extra.RequiredInt("anything")
+	if err := obj.ValidateAll(); err != nil {
+		t.Error(err)
+	}
+	want := decodeConfig{
+		commonConfig: commonConfig{Name: "svc"},
+		Host:         "db.example.com",
+		Port:         5432,
+		Timeout:      30,
+		Workers:      2,
+		Verbose:      true,
+		Tags:         []string{"a", "b"},
+		Zones:        []string{"us", "eu"},
+		IDs:          []int64{3, 2, 1},
+		DB:           dbConfig{User: "admin", Pool: 10},
+		Replica:      &dbConfig{User: "reader", Pool: 4},
+		Skipped:      "keep",
+	}
+	extra := cfg.Extra
+	cfg.Extra = Obj{}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got  %+v\nwant %+v", cfg, want)
+	}
+	if g, e := extra.Map(), map[string]interface{}{"anything": json.Number("1")}; !reflect.DeepEqual(g, e) {
+		t.Errorf("Extra = %v; want %v", g, e)
+	}
+}
+
+func TestDecodeOptionalObject(t *testing.T) {
+	var cfg struct {
+		DB dbConfig `jsoncfg:"db"`
+	}
+	obj := NewObj(nil)
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.ValidateAll(); err != nil {
+		t.Errorf("ValidateAll = %v; want no errors for a missing optional object", err)
+	}
+	if cfg.DB.Pool != 4 {
+		t.Errorf("db.pool = %d; want the default 4", cfg.DB.Pool)
+	}
+
+	obj = NewObj(map[string]interface{}{"db": map[string]interface{}{}})
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.ValidateAll(); err == nil || !strings.Contains(err.Error(), `"db.user"`) {
+		t.Errorf("ValidateAll = %v; want db.user missing from a present object", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	obj := NewObj(map[string]interface{}{
+		"host": 1,
+		"db":   map[string]interface{}{"pool": "many", "bogus": true},
+		"typo": "x",
+	})
+	var cfg decodeConfig
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	err := obj.ValidateAll()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`Expected config key "host" to be a string`,
+		`Missing required config key "db.user" (string)`,
+		`Expected config key "db.pool" to be a number, not string`,
+		`Unknown key "db.bogus"`,
+		`Unknown key "typo"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestDecodeUnits(t *testing.T) {
+	var cfg struct {
+		Timeout time.Duration `jsoncfg:"timeout"`
+		Grace   time.Duration `jsoncfg:"grace" default:"5s"`
+		Start   time.Time     `jsoncfg:"start,required"`
+		Day     time.Time     `jsoncfg:"day" layout:"2006-01-02"`
+		Cache   int64         `jsoncfg:"cache,size"`
+		Disk    int           `jsoncfg:"disk,size"`
+		Body    int64         `jsoncfg:"body,size" default:"1MiB"`
+	}
+	obj, err := ReadFile("testdata/units.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	obj.RequiredInt("plain")
+	if err := obj.ValidateAll(); err != nil {
+		t.Error(err)
+	}
+	if cfg.Timeout != 90*time.Second || cfg.Grace != 5*time.Second {
+		t.Errorf("durations = %v, %v", cfg.Timeout, cfg.Grace)
+	}
+	if !cfg.Start.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !cfg.Day.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("times = %v, %v", cfg.Start, cfg.Day)
+	}
+	if cfg.Cache != 512<<20 || cfg.Disk != 1500000000 || cfg.Body != 1<<20 {
+		t.Errorf("sizes = %d, %d, %d", cfg.Cache, cfg.Disk, cfg.Body)
+	}
+
+	var badSize struct {
+		S string `jsoncfg:"s,size"`
+	}
+	if err := (Obj{}).Decode(&badSize); err == nil {
+		t.Error("expected an error for the size option on a string")
+	}
+}
+
+type upstream struct {
+	Name   string  `jsoncfg:"name,required"`
+	Port   int     `jsoncfg:"port"`
+	Weight float64 `jsoncfg:"weight" default:"1"`
+}
+
+func TestDecodeLists(t *testing.T) {
+	var cfg struct {
+		Ratio     float64         `jsoncfg:"ratio"`
+		Weights   []float64       `jsoncfg:"weights"`
+		Flags     []bool          `jsoncfg:"flags"`
+		Intervals []time.Duration `jsoncfg:"intervals"`
+		Retries   []time.Duration `jsoncfg:"retries" default:"1s, 2s"`
+		Upstreams []upstream      `jsoncfg:"upstreams"`
+		Raw       []Obj           `jsoncfg:"bad_upstreams"`
+	}
+	obj, err := ReadFile("testdata/lists.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := obj.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Ratio != 0.75 || len(cfg.Weights) != 3 || len(cfg.Flags) != 3 || len(cfg.Intervals) != 2 {
+		t.Errorf("got %+v", cfg)
+	}
+	if w := []time.Duration{time.Second, 2 * time.Second}; !reflect.DeepEqual(cfg.Retries, w) {
+		t.Errorf("Retries = %v; want %v", cfg.Retries, w)
+	}
+	want := []upstream{{"a", 80, 1}, {"b", 81, 1}}
+	if !reflect.DeepEqual(cfg.Upstreams, want) {
+		t.Errorf("Upstreams = %+v; want %+v", cfg.Upstreams, want)
+	}
+	if cfg.Raw != nil {
+		t.Errorf("Raw = %v; want nil", cfg.Raw)
+	}
+	err = obj.ValidateAll()
+	for _, w := range []string{
+		`Unknown key "upstreams.1.bogus"`,
+		`Expected config key "bad_upstreams" index 1 to be an object, not string`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), w) {
+			t.Errorf("ValidateAll = %v; want it to contain %s", err, w)
+		}
+	}
+}
+
+func TestDecodeBadTarget(t *testing.T) {
+	var cfg decodeConfig
+	if err := (Obj{}).Decode(cfg); err == nil {
+		t.Error("expected an error decoding into a non-pointer")
+	}
+	var bad struct {
+		C chan int
+	}
+	if err := (Obj{}).Decode(&bad); err == nil {
+		t.Error("expected an error decoding an unsupported field type")
+	}
+	var badDefault struct {
+		N int `default:"x"`
+	}
+	if err := (Obj{}).Decode(&badDefault); err == nil {
+		t.Error("expected an error for a malformed default")
+	}
+}
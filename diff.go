@@ -0,0 +1,281 @@
+package jsoncfgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// A ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	Added   ChangeKind = iota // the value is only in the second config
+	Removed                   // the value is only in the first config
+	Changed                   // the value differs between the configs
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// A Change is a difference between two configs found by Diff.
+type Change struct {
+	Kind ChangeKind
+	Path string      // the JSON Pointer of the value
+	Old  interface{} // the value in the first config, nil if Added
+	New  interface{} // the value in the second config, nil if Removed
+	// Secret reports that Old or New is a Secret or _secret expression,
+	// so that WriteDiff and WriteDiffJSON mask them.
+	Secret bool
+}
+
+// A DiffOption changes how WriteDiff and WriteDiffJSON write changes.
+type DiffOption func(*diffOptions)
+
+type diffOptions struct {
+	maskKeys *regexp.Regexp // matches whole keys
+}
+
+// MaskKeys makes WriteDiff and WriteDiffJSON also mask the values of the
+// keys that re matches in full, such as "password" for
+//
+//	regexp.MustCompile(`(?i)password|api_key`)
+//
+// but not "password_hint", and the values nested in them, for configs
+// whose secrets are not marked with _secret.
+func MaskKeys(re *regexp.Regexp) DiffOption {
+	anchored := regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	return func(o *diffOptions) { o.maskKeys = anchored }
+}
+
+func newDiffOptions(opts []DiffOption) *diffOptions {
+	o := new(diffOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// maskKey reports whether the value of key is masked.
+func (o *diffOptions) maskKey(key string) bool {
+	return o.maskKeys != nil && o.maskKeys.MatchString(key)
+}
+
+// maskPath reports whether the value at the JSON Pointer ptr is masked
+// because it is nested in a key that is.
+func (o *diffOptions) maskPath(ptr string) bool {
+	segs, _ := splitPath(ptr)
+	for _, seg := range segs {
+		if o.maskKey(seg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the differences between the configs a and b, ordered by
+// path. Objects are compared key by key and lists element by element, so
+// that a change deep in a config is reported at its own path, and a value
+// added to or removed from a list is reported at its index. A value whose
+// type changed is reported as Changed. Numbers are compared by value, and
+// a Secret is equal to the value it holds.
+func Diff(a, b Obj) []Change {
+	var changes []Change
+	diffValues(a.m, b.m, "", &changes)
+	return changes
+}
+
+func diffValues(a, b interface{}, ptr string, changes *[]Change) {
+	if isSecret(a) || isSecret(b) {
+		if !jsonEqual(reveal(a), reveal(b)) {
+			*changes = append(*changes, Change{Kind: Changed, Path: ptr, Old: a, New: b, Secret: true})
+		}
+		return
+	}
+	switch ta := a.(type) {
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(ta)+len(tb))
+		for k := range ta {
+			keys = append(keys, k)
+		}
+		for k := range tb {
+			if _, ok := ta[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kPtr := ptr + "/" + pointerEscaper.Replace(k)
+			va, inA := ta[k]
+			vb, inB := tb[k]
+			switch {
+			case !inA:
+				*changes = append(*changes, Change{Kind: Added, Path: kPtr, New: vb, Secret: isSecret(vb)})
+			case !inB:
+				*changes = append(*changes, Change{Kind: Removed, Path: kPtr, Old: va, Secret: isSecret(va)})
+			default:
+				diffValues(va, vb, kPtr, changes)
+			}
+		}
+		return
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(ta) || i < len(tb); i++ {
+			iPtr := ptr + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(ta):
+				*changes = append(*changes, Change{Kind: Added, Path: iPtr, New: tb[i], Secret: isSecret(tb[i])})
+			case i >= len(tb):
+				*changes = append(*changes, Change{Kind: Removed, Path: iPtr, Old: ta[i], Secret: isSecret(ta[i])})
+			default:
+				diffValues(ta[i], tb[i], iPtr, changes)
+			}
+		}
+		return
+	}
+	if !jsonEqual(a, b) {
+		*changes = append(*changes, Change{Kind: Changed, Path: ptr, Old: a, New: b})
+	}
+}
+
+// isSecret reports whether v is a Secret or, in a config read with
+// ConfigParser.Raw, a _secret expression.
+func isSecret(v interface{}) bool {
+	switch t := v.(type) {
+	case Secret:
+		return true
+	case []interface{}:
+		return len(t) > 0 && t[0] == "_secret"
+	}
+	return false
+}
+
+// WriteDiff writes changes to w as text, one line per change, marked "+"
+// if Added, "-" if Removed and "~" if Changed:
+//
+//	~ /server/port: 8080 -> 9090
+//	~ /timeout: 30 -> "30s" (number -> string)
+//	+ /tags/2: "canary"
+//	- /debug: true
+//
+// Values are written as JSON, with secrets masked.
+func WriteDiff(w io.Writer, changes []Change, opts ...DiffOption) error {
+	o := newDiffOptions(opts)
+	for _, c := range changes {
+		var err error
+		secret := c.Secret || o.maskPath(c.Path)
+		switch c.Kind {
+		case Added:
+			_, err = fmt.Fprintf(w, "+ %s: %s\n", c.Path, o.diffValue(c.New, secret))
+		case Removed:
+			_, err = fmt.Fprintf(w, "- %s: %s\n", c.Path, o.diffValue(c.Old, secret))
+		default:
+			typeChange := ""
+			if ot, nt := jsonType(reveal(c.Old)), jsonType(reveal(c.New)); ot != nt {
+				typeChange = fmt.Sprintf(" (%s -> %s)", ot, nt)
+			}
+			_, err = fmt.Fprintf(w, "~ %s: %s -> %s%s\n", c.Path, o.diffValue(c.Old, secret), o.diffValue(c.New, secret), typeChange)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDiffJSON writes changes to w as a JSON list of objects with the
+// members "kind", "path", and "old" and "new" when they apply, with
+// secrets masked.
+func WriteDiffJSON(w io.Writer, changes []Change, opts ...DiffOption) error {
+	o := newDiffOptions(opts)
+	type jsonChange struct {
+		Kind string          `json:"kind"`
+		Path string          `json:"path"`
+		Old  json.RawMessage `json:"old,omitempty"`
+		New  json.RawMessage `json:"new,omitempty"`
+	}
+	out := make([]jsonChange, len(changes))
+	for i, c := range changes {
+		out[i] = jsonChange{Kind: c.Kind.String(), Path: c.Path}
+		secret := c.Secret || o.maskPath(c.Path)
+		var err error
+		if c.Kind != Added {
+			if out[i].Old, err = marshalValue(o.maskSecrets(c.Old, secret)); err != nil {
+				return err
+			}
+		}
+		if c.Kind != Removed {
+			if out[i].New, err = marshalValue(o.maskSecrets(c.New, secret)); err != nil {
+				return err
+			}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// diffValue formats the value v of a change as JSON.
+func (o *diffOptions) diffValue(v interface{}, secret bool) string {
+	b, err := marshalValue(o.maskSecrets(v, secret))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// marshalValue returns v as compact JSON, without escaping the characters
+// special to HTML.
+func marshalValue(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+// maskSecrets returns v, or secretMask if secret is true, with the values
+// of the masked keys and the _secret expressions nested in v masked.
+func (o *diffOptions) maskSecrets(v interface{}, secret bool) interface{} {
+	if secret || isSecret(v) {
+		return secretMask
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, sv := range t {
+			m[k] = o.maskSecrets(sv, o.maskKey(k))
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, sv := range t {
+			l[i] = o.maskSecrets(sv, false)
+		}
+		return l
+	}
+	return v
+}
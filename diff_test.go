@@ -0,0 +1,126 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func parseObj(t *testing.T, s string) Obj {
+	t.Helper()
+	var m map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(s))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	return NewObj(m)
+}
+
+func TestDiff(t *testing.T) {
+	a := parseObj(t, `{
+		"name": "billing",
+		"debug": true,
+		"timeout": 30,
+		"ratio": 1.0,
+		"server": {"host": "localhost", "port": 8080},
+		"db": {"user": "app", "password": "hunter2"},
+		"tags": ["a", "b"],
+		"max_tokens": 100,
+		"a/b": null
+	}`)
+	b := parseObj(t, `{
+		"name": "billing",
+		"timeout": "30s",
+		"ratio": 1,
+		"server": {"host": "localhost", "port": 9090, "tls": {"cert": "<cert>", "private_key": "k"}},
+		"db": {"user": "app", "password": "swordfish"},
+		"tags": ["a", "c", "d"],
+		"max_tokens": 200,
+		"a/b": 1
+	}`)
+	changes := Diff(a, b)
+	mask := MaskKeys(regexp.MustCompile(`(?i)password|private_key|token`))
+
+	var text strings.Builder
+	if err := WriteDiff(&text, changes, mask); err != nil {
+		t.Fatal(err)
+	}
+	want := `~ /a~1b: null -> 1 (null -> number)
+~ /db/password: "***" -> "***"
+- /debug: true
+~ /max_tokens: 100 -> 200
+~ /server/port: 8080 -> 9090
++ /server/tls: {"cert":"<cert>","private_key":"***"}
+~ /tags/1: "b" -> "c"
++ /tags/2: "d"
+~ /timeout: 30 -> "30s" (number -> string)
+`
+	if g := text.String(); g != want {
+		t.Errorf("WriteDiff:\n%s\nwant:\n%s", g, want)
+	}
+	if c := changes[1]; c.Kind != Changed || c.Old != "hunter2" || c.New != "swordfish" || c.Secret {
+		t.Errorf("change 1 = %+v; want the unmasked password change", c)
+	}
+	text.Reset()
+	WriteDiff(&text, changes[1:2])
+	if g, e := text.String(), "~ /db/password: \"hunter2\" -> \"swordfish\"\n"; g != e {
+		t.Errorf("WriteDiff without MaskKeys = %q; want %q", g, e)
+	}
+
+	var js strings.Builder
+	if err := WriteDiffJSON(&js, changes[:3], mask); err != nil {
+		t.Fatal(err)
+	}
+	want = `[
+  {
+    "kind": "changed",
+    "path": "/a~1b",
+    "old": null,
+    "new": 1
+  },
+  {
+    "kind": "changed",
+    "path": "/db/password",
+    "old": "***",
+    "new": "***"
+  },
+  {
+    "kind": "removed",
+    "path": "/debug",
+    "old": true
+  }
+]
+`
+	if g := js.String(); g != want {
+		t.Errorf("WriteDiffJSON:\n%s\nwant:\n%s", g, want)
+	}
+
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("Diff(a, a) = %v; want no changes", changes)
+	}
+}
+
+func TestDiffRaw(t *testing.T) {
+	t.Setenv("JSONCFGO_TEST_HOST", "db.example.com")
+	t.Setenv("APP_DB_USER", "admin")
+	c := ConfigParser{Raw: true, EnvPrefix: "APP"}
+	raw, err := c.ReadFile("testdata/diff.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = ConfigParser{EnvPrefix: "APP"}
+	evaluated, err := c.ReadFile("testdata/diff.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	WriteDiff(&text, Diff(NewObj(raw), NewObj(evaluated)))
+	want := `~ /db/host: ["_env","${JSONCFGO_TEST_HOST}"] -> "db.example.com" (array -> string)
+~ /db/user: "app" -> "admin"
+`
+	if g := text.String(); g != want {
+		t.Errorf("raw and evaluated configs differ by:\n%s\nwant:\n%s", g, want)
+	}
+}
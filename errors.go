@@ -0,0 +1,239 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of key errors, for use with errors.Is. Every error recorded by
+// the accessors of an Obj and reported by Validate and ValidateAll is one
+// of these kinds.
+var (
+	// ErrMissingKey is the kind of MissingKeyError.
+	ErrMissingKey = errors.New("missing required config key")
+	// ErrWrongType is the kind of TypeError.
+	ErrWrongType = errors.New("config value has the wrong type")
+	// ErrBadValue is the kind of ValueError.
+	ErrBadValue = errors.New("config value cannot be converted")
+	// ErrUnknownKey is the kind of UnknownKeyError.
+	ErrUnknownKey = errors.New("unknown config key")
+	// ErrConstraint is the kind of ConstraintError.
+	ErrConstraint = errors.New("config value violates a constraint")
+)
+
+// A SyntaxError reports a config file that is not valid JSON.
+type SyntaxError struct {
+	File      string
+	Line      int
+	Column    int
+	Offset    int64  // byte offset in File
+	Highlight string // the lines around the error, marking its position
+	Err       error  // the error reported by the JSON decoder
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("error parsing JSON object in config file %s:\nError at line %d, column %d (file offset %d):\n%s\n%v",
+		e.File, e.Line, e.Column, e.Offset, e.Highlight, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// An IncludeCycleError reports a config file that includes itself,
+// directly or through other files.
+type IncludeCycleError struct {
+	File  string   // the file included twice
+	Chain []string // the files being read, from the root file to File
+}
+
+func (e *IncludeCycleError) Error() string {
+	return fmt.Sprintf("ConfigParser include cycle detected reading config: %v (include chain: %s)",
+		e.File, strings.Join(e.Chain, " -> "))
+}
+
+// An ExpansionError reports an expression of a config file that could not
+// be evaluated. Errors of files included with _fileobj are wrapped in the
+// ExpansionError of the _fileobj expression.
+type ExpansionError struct {
+	File     string // the file being read
+	Path     string // the dotted key path of the expression in File
+	Expander string // the name of the expander, if any
+	Err      error
+}
+
+func (e *ExpansionError) Error() string {
+	if e.Expander == "" {
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: value error %v", e.Path, e.Err)
+}
+
+func (e *ExpansionError) Unwrap() error { return e.Err }
+
+// A MissingKeyError reports a required key that is not in the config.
+type MissingKeyError struct {
+	Key  string // the key, or its dotted path when reported by ValidateAll
+	Type string // a description of the expected value
+}
+
+func (e *MissingKeyError) Error() string {
+	return fmt.Sprintf("Missing required config key %q (%s)", e.Key, e.Type)
+}
+
+func (e *MissingKeyError) Is(target error) bool { return target == ErrMissingKey }
+
+// A TypeError reports a config value of the wrong JSON type.
+type TypeError struct {
+	Key   string // the key, or its dotted path when reported by ValidateAll
+	Index int    // the index of the bad element of a list, or -1
+	Want  string // a description of the expected value
+	Got   string // the Go type of the value found
+}
+
+func (e *TypeError) Error() string {
+	return fmt.Sprintf("Expected config key %q%s to be %s, not %s", e.Key, indexSuffix(e.Index), e.Want, e.Got)
+}
+
+func (e *TypeError) Is(target error) bool { return target == ErrWrongType }
+
+// A ValueError reports a config value of the right JSON type that cannot
+// be converted to the requested Go type.
+type ValueError struct {
+	Key   string // the key, or its dotted path when reported by ValidateAll
+	Index int    // the index of the bad element of a list, or -1
+	Value interface{}
+	Type  string // the requested type
+	Err   error  // the reason for the failure
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("Config key %q%s value %s cannot be converted to %s: %v",
+		e.Key, indexSuffix(e.Index), formatValue(e.Value), e.Type, e.Err)
+}
+
+func (e *ValueError) Unwrap() error { return e.Err }
+
+func (e *ValueError) Is(target error) bool { return target == ErrBadValue }
+
+// A ConstraintError reports a config value that was read successfully but
+// violates a Constraint.
+type ConstraintError struct {
+	Key   string // the key, or its dotted path when reported by ValidateAll
+	Value interface{}
+	Err   error // the violation, such as "must be at most 65535"
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("Config key %q value %s %v", e.Key, formatValue(e.Value), e.Err)
+}
+
+func (e *ConstraintError) Unwrap() error { return e.Err }
+
+func (e *ConstraintError) Is(target error) bool { return target == ErrConstraint }
+
+// An UnknownKeyError reports a key of the config that was never read.
+type UnknownKeyError struct {
+	Key string // the key, or its dotted path when reported by ValidateAll
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("Unknown key %q", e.Key)
+}
+
+func (e *UnknownKeyError) Is(target error) bool { return target == ErrUnknownKey }
+
+// A MultiError holds the errors found by Validate or ValidateAll when
+// there is more than one. errors.Is and errors.As look through all of
+// them.
+type MultiError []error
+
+func (e MultiError) Error() string {
+	strs := make([]string, len(e))
+	for i, err := range e {
+		strs[i] = err.Error()
+	}
+	return "Multiple errors: " + strings.Join(strs, ", ")
+}
+
+// Errors returns the individual errors.
+func (e MultiError) Errors() []error { return []error(e) }
+
+func (e MultiError) Unwrap() []error { return []error(e) }
+
+func indexSuffix(index int) string {
+	if index < 0 {
+		return ""
+	}
+	return fmt.Sprintf(" index %d", index)
+}
+
+func missingKey(key, typ string) error {
+	return &MissingKeyError{Key: key, Type: typ}
+}
+
+func wrongType(key string, index int, want string, got interface{}) error {
+	return &TypeError{Key: key, Index: index, Want: want, Got: fmt.Sprintf("%T", got)}
+}
+
+// badValue returns the error for the value of key in jc, at index in the
+// list at key if index is not -1, that cannot be converted to typ. The
+// value is redacted if it is a Secret in jc.
+func (jc Obj) badValue(key string, index int, value interface{}, typ string, err error) error {
+	if s, ok := jc.secretAt(key, index); ok {
+		value, err = s, redactError(err, s)
+	}
+	return &ValueError{Key: key, Index: index, Value: value, Type: typ, Err: err}
+}
+
+// constraintError returns the error for the value v of key in jc that
+// violates a constraint. The value is redacted if it is a Secret in jc.
+func (jc Obj) constraintError(key string, v interface{}, err error) error {
+	if s, ok := jc.secretAt(key, -1); ok {
+		v, err = s, redactError(err, s)
+	}
+	return &ConstraintError{Key: key, Value: v, Err: err}
+}
+
+// atPath returns err, recorded for an object at path, with its key
+// replaced by its full dotted path.
+func atPath(path []string, err error) error {
+	if len(path) == 0 {
+		return err
+	}
+	prefix := strings.Join(path, ".") + "."
+	switch e := err.(type) {
+	case *MissingKeyError:
+		c := *e
+		c.Key = prefix + c.Key
+		return &c
+	case *TypeError:
+		c := *e
+		c.Key = prefix + c.Key
+		return &c
+	case *ValueError:
+		c := *e
+		c.Key = prefix + c.Key
+		return &c
+	case *ConstraintError:
+		c := *e
+		c.Key = prefix + c.Key
+		return &c
+	case *UnknownKeyError:
+		c := *e
+		c.Key = prefix + c.Key
+		return &c
+	}
+	return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+}
+
+// joinErrors returns nil, the only error of errs, or a MultiError holding
+// all of them.
+func joinErrors(errList []error) error {
+	switch len(errList) {
+	case 0:
+		return nil
+	case 1:
+		return errList[0]
+	}
+	return MultiError(errList)
+}
@@ -0,0 +1,107 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSyntaxErrorType(t *testing.T) {
+	_, err := ReadFile("testdata/syntax.json")
+	var serr *SyntaxError
+	if !errors.As(err, &serr) {
+		t.Fatalf("error %v is not a *SyntaxError", err)
+	}
+	if serr.File != "testdata/syntax.json" || serr.Line != 3 {
+		t.Errorf("SyntaxError at %s line %d; want testdata/syntax.json line 3", serr.File, serr.Line)
+	}
+
+	// Syntax errors of included files are found through the include.
+	_, err = ReadFile("testdata/includebad.json")
+	var eerr *ExpansionError
+	if !errors.As(err, &eerr) || eerr.Expander != "_fileobj" || eerr.Path != "inc" {
+		t.Errorf("error %v is not an ExpansionError of _fileobj at inc", err)
+	}
+	if !errors.As(err, &serr) || serr.Line != 3 {
+		t.Errorf("error %v does not wrap the SyntaxError of the included file", err)
+	}
+}
+
+func TestIncludeCycleErrorType(t *testing.T) {
+	_, err := ReadFile("testdata/loop1.json")
+	var cerr *IncludeCycleError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("error %v is not an *IncludeCycleError", err)
+	}
+	loop1, _ := filepath.Abs("testdata/loop1.json")
+	loop2, _ := filepath.Abs("testdata/loop2.json")
+	if want := []string{loop1, loop2, loop1}; !reflect.DeepEqual(cerr.Chain, want) {
+		t.Errorf("Chain = %q; want %q", cerr.Chain, want)
+	}
+}
+
+func TestExpansionErrorType(t *testing.T) {
+	os.Unsetenv("TEST_JSONCFGO_UNSET")
+	_, err := ReadFile("testdata/badenv.json")
+	var eerr *ExpansionError
+	if !errors.As(err, &eerr) {
+		t.Fatalf("error %v is not an *ExpansionError", err)
+	}
+	abs, _ := filepath.Abs("testdata/badenv.json")
+	if eerr.Expander != "_env" || eerr.Path != "outer.list.1" || eerr.File != abs {
+		t.Errorf("ExpansionError = %+v", eerr)
+	}
+}
+
+func TestKeyErrorTypes(t *testing.T) {
+	obj := NewObj(map[string]interface{}{
+		"str":  1,
+		"bool": "maybe",
+		"list": []interface{}{"a", 2},
+		"sub":  map[string]interface{}{"extra": 1},
+		"typo": 1,
+	})
+	obj.RequiredString("str")
+	obj.RequiredString("missing")
+	obj.RequiredBool("bool")
+	obj.RequiredList("list")
+	obj.RequiredObject("sub")
+	err := obj.ValidateAll()
+
+	var merr MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("error %v is not a MultiError", err)
+	}
+	if g, e := len(merr.Errors()), 6; g != e {
+		t.Errorf("got %d errors; want %d: %v", g, e, err)
+	}
+	for _, kind := range []error{ErrMissingKey, ErrWrongType, ErrBadValue, ErrUnknownKey} {
+		if !errors.Is(err, kind) {
+			t.Errorf("errors.Is(%v, %v) = false", err, kind)
+		}
+	}
+	var missing *MissingKeyError
+	if !errors.As(err, &missing) || missing.Key != "missing" || missing.Type != "string" {
+		t.Errorf("MissingKeyError = %+v", missing)
+	}
+	var terr *TypeError
+	if !errors.As(err, &terr) || terr.Key != "str" || terr.Index != -1 || terr.Got != "int" {
+		t.Errorf("TypeError = %+v", terr)
+	}
+	var verr *ValueError
+	if !errors.As(err, &verr) || verr.Key != "bool" || verr.Value != "maybe" {
+		t.Errorf("ValueError = %+v", verr)
+	}
+	var unknown []string
+	for _, e := range merr {
+		var uerr *UnknownKeyError
+		if errors.As(e, &uerr) {
+			unknown = append(unknown, uerr.Key)
+		}
+	}
+	if want := []string{"typo", "sub.extra"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("unknown keys = %q; want %q", unknown, want)
+	}
+}
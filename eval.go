@@ -16,21 +16,33 @@ limitations under the License.
 Changes made:
 * Changed package name from jsonconfig to jsoncfgo
 * Added Load function in jsoncfgo.go
+* Added pluggable expander registry
+* Numbers are decoded as json.Number
+* Added relaxed mode permitting comments and trailing commas
+* Added ReadFiles for merging layered config files
+* Added environment variable overrides
+* Added Files and a Watcher for reloading changed configs
+* Added source positions of values and JSON Schema validation
+* Added Raw mode reading configs without evaluating expressions
+* Added origins of values recording their expressions and includes
+* Added secret values redacted when printed
 */
 
 package jsoncfgo
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"camlistore.org/pkg/errorutil"
 	"camlistore.org/pkg/osutil"
@@ -62,13 +74,53 @@ type File interface {
 // ConfigParser specifies the environment for parsing a config file
 // and evaluating expressions.
 type ConfigParser struct {
-	rootJSON Obj
+	rootJSON map[string]interface{}
 
 	touchedFiles map[string]bool
 	includeStack stringVector
+	keyPath      []string
+	expanders    map[string]ExpanderFunc
 
 	// Open optionally specifies an opener function.
 	Open func(filename string) (File, error)
+
+	// Relaxed permits line (//) and block (/* */) comments, trailing
+	// commas and unquoted object keys in config files.
+	Relaxed bool
+
+	// Raw disables the evaluation of expressions and environment
+	// overrides, so that configs are read as written. Files are still
+	// merged by ReadFiles.
+	Raw bool
+
+	// ListStrategy specifies how ReadFiles merges lists, unless
+	// overridden for a dotted key path in ListStrategies. Lists nested
+	// in the elements of other lists are named by the path of the outer
+	// list followed by their key.
+	ListStrategy   ListStrategy
+	ListStrategies map[string]ListStrategy
+
+	// MergeKey names the key identifying the objects of the lists
+	// merged with ListMergeByKey. It defaults to "name".
+	MergeKey string
+
+	// EnvPrefix, if not empty, lets environment variables override any
+	// value of the config. The variable for a value is named by
+	// EnvVarName: with EnvPrefix "APP", APP_DATABASE_HOST overrides
+	// database.host. Its contents are converted to the type of the value
+	// from the config file.
+	EnvPrefix string
+
+	envOverrides map[string]string // variable names by JSON Pointer
+
+	// positions holds the origin of each value of rootJSON by JSON
+	// Pointer. filePositions holds those of the file being read, rooted
+	// at rootPath, the key path of the file in the config. exprSource is
+	// set by the builtin expanders to what they read, for Origin.Source.
+	positions     map[string]Origin
+	filePositions map[string]Origin
+	rootPath      []string
+	exprSource    string
 }
 
 func (c *ConfigParser) open(filename string) (File, error) {
@@ -83,8 +135,30 @@ var envPattern = regexp.MustCompile(`\$\{[A-Za-z0-9_]+\}`)
 
 func (c *ConfigParser) ReadFile(path string) (m map[string]interface{}, err error) {
 	c.touchedFiles = make(map[string]bool)
+	c.positions = nil
+	c.filePositions = make(map[string]Origin)
+	defer func() { c.filePositions = nil }()
 	c.rootJSON, err = c.recursiveReadJSON(path)
-	return c.rootJSON, err
+	if err != nil {
+		return nil, err
+	}
+	if err = c.applyEnvOverrides(c.rootJSON); err != nil {
+		return nil, err
+	}
+	c.positions = settlePositions(c.rootJSON, c.filePositions)
+	return c.rootJSON, nil
+}
+
+// Files returns the absolute paths of the config files read by the last
+// call to ReadFile or ReadFiles, including those included with _fileobj,
+// whether or not the read succeeded.
+func (c *ConfigParser) Files() []string {
+	files := make([]string, 0, len(c.touchedFiles))
+	for f := range c.touchedFiles {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
 }
 
 // Decodes and evaluates a json config file, watching for include cycles.
@@ -95,54 +169,162 @@ func (c *ConfigParser) recursiveReadJSON(configPath string) (decodedObject map[s
 		return nil, fmt.Errorf("Failed to expand absolute path for %s", configPath)
 	}
 	if c.touchedFiles[absConfigPath] {
-		return nil, fmt.Errorf("ConfigParser include cycle detected reading config: %v",
-			absConfigPath)
+		chain := append(append([]string(nil), c.includeStack.v...), absConfigPath)
+		return nil, &IncludeCycleError{File: absConfigPath, Chain: chain}
 	}
 	c.touchedFiles[absConfigPath] = true
 
 	c.includeStack.Push(absConfigPath)
 	defer c.includeStack.Pop()
+	defer func(path []string) { c.keyPath = path }(c.keyPath)
+	defer func(path []string) { c.rootPath = path }(c.rootPath)
+	c.rootPath = append(append([]string(nil), c.rootPath...), c.keyPath...)
 
 	var f File
 	if f, err = c.open(configPath); err != nil {
-		return nil, fmt.Errorf("Failed to open config: %v", err)
+		return nil, fmt.Errorf("Failed to open config: %w", err)
 	}
 	defer f.Close()
 
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read config %s: %w", f.Name(), err)
+	}
+	src := data
+	var offsets []int64
+	if c.Relaxed {
+		if src, offsets, err = relaxJSON(data); err != nil {
+			return nil, syntaxError(f.Name(), data, err.(*relaxError).offset, err)
+		}
+	}
+
 	decodedObject = make(map[string]interface{})
-	dj := json.NewDecoder(f)
+	dj := json.NewDecoder(bytes.NewReader(src))
+	dj.UseNumber()
 	if err = dj.Decode(&decodedObject); err != nil {
-		extra := ""
 		if serr, ok := err.(*json.SyntaxError); ok {
-			if _, serr := f.Seek(0, os.SEEK_SET); serr != nil {
-				log.Fatalf("seek error: %v", serr)
+			offset := serr.Offset
+			if c.Relaxed {
+				offset = relaxedOffset(offsets, offset)
 			}
-			line, col, highlight := errorutil.HighlightBytePosition(f, serr.Offset)
-			extra = fmt.Sprintf(":\nError at line %d, column %d (file offset %d):\n%s",
-				line, col, serr.Offset, highlight)
+			return nil, syntaxError(f.Name(), data, offset, err)
 		}
-		return nil, fmt.Errorf("error parsing JSON object in config file %s%s\n%v",
-			f.Name(), extra, err)
+		return nil, fmt.Errorf("error parsing JSON object in config file %s\n%w",
+			f.Name(), err)
+	}
+	if c.filePositions != nil {
+		c.recordPositions(f.Name(), data, src, offsets, jsonPointer(c.rootPath))
 	}
 
+	if c.Raw {
+		return decodedObject, nil
+	}
 	if err = c.evaluateExpressions(decodedObject, nil, false); err != nil {
-		return nil, fmt.Errorf("error expanding JSON config expressions in %s:\n%v",
+		return nil, fmt.Errorf("error expanding JSON config expressions in %s:\n%w",
 			f.Name(), err)
 	}
 
 	return decodedObject, nil
 }
 
-type expanderFunc func(c *ConfigParser, v []interface{}) (interface{}, error)
+// syntaxError returns the error for a syntax error at offset in the
+// contents data of the config file name, highlighting its position.
+func syntaxError(name string, data []byte, offset int64, err error) error {
+	line, col, highlight := errorutil.HighlightBytePosition(bytes.NewReader(data), offset)
+	return &SyntaxError{
+		File:      name,
+		Line:      line,
+		Column:    col,
+		Offset:    offset,
+		Highlight: highlight,
+		Err:       err,
+	}
+}
+
+// An ExpanderFunc evaluates a named expression of the form
+// ["name", args...]. args holds the unevaluated arguments that follow
+// the name; an expander that accepts nested expressions should pass
+// them to c.EvalValue. Errors are reported with the key path of the
+// expression being evaluated, which is also available from c.KeyPath.
+type ExpanderFunc func(c *ConfigParser, args []interface{}) (interface{}, error)
+
+var (
+	defaultExpandersMu sync.RWMutex
+	defaultExpanders   map[string]ExpanderFunc
+)
+
+func init() {
+	defaultExpanders = map[string]ExpanderFunc{
+		"_env":     (*ConfigParser).expandEnv,
+		"_fileobj": (*ConfigParser).expandFile,
+		"_secret":  (*ConfigParser).expandSecret,
+	}
+}
+
+func checkExpander(name string, fn ExpanderFunc) {
+	if !strings.HasPrefix(name, "_") || len(name) < 2 {
+		panic(fmt.Sprintf("jsoncfgo: invalid expander name %q; must start with an underscore", name))
+	}
+	if fn == nil {
+		panic(fmt.Sprintf("jsoncfgo: nil expander for %q", name))
+	}
+}
+
+// RegisterExpander makes fn available to every ConfigParser under name.
+// Names must start with an underscore so that ordinary lists of strings
+// are never mistaken for expressions. It panics if name is already
+// registered.
+func RegisterExpander(name string, fn ExpanderFunc) {
+	checkExpander(name, fn)
+	defaultExpandersMu.Lock()
+	defer defaultExpandersMu.Unlock()
+	if _, dup := defaultExpanders[name]; dup {
+		panic(fmt.Sprintf("jsoncfgo: RegisterExpander called twice for %q", name))
+	}
+	defaultExpanders[name] = fn
+}
+
+// RegisterExpander makes fn available to c under name, taking precedence
+// over an expander of the same name registered with the package-level
+// RegisterExpander, including the builtin _env, _fileobj and _secret.
+func (c *ConfigParser) RegisterExpander(name string, fn ExpanderFunc) {
+	checkExpander(name, fn)
+	if c.expanders == nil {
+		c.expanders = make(map[string]ExpanderFunc)
+	}
+	c.expanders[name] = fn
+}
+
+func (c *ConfigParser) namedExpander(name string) (ExpanderFunc, bool) {
+	if fn, ok := c.expanders[name]; ok {
+		return fn, true
+	}
+	defaultExpandersMu.RLock()
+	defer defaultExpandersMu.RUnlock()
+	fn, ok := defaultExpanders[name]
+	return fn, ok
+}
 
-func namedExpander(name string) (expanderFunc, bool) {
-	switch name {
-	case "_env":
-		return expanderFunc((*ConfigParser).expandEnv), true
-	case "_fileobj":
-		return expanderFunc((*ConfigParser).expandFile), true
+// KeyPath returns the dotted path, relative to the file being read, of
+// the value currently being evaluated. List elements are named by their
+// index. It is meant to be called from an ExpanderFunc.
+func (c *ConfigParser) KeyPath() string {
+	return strings.Join(c.keyPath, ".")
+}
+
+// CurrentFile returns the absolute path of the config file currently
+// being read, or the empty string if no file is being read.
+func (c *ConfigParser) CurrentFile() string {
+	if len(c.includeStack.v) == 0 {
+		return ""
 	}
-	return nil, false
+	return c.includeStack.Last()
+}
+
+// EvalValue evaluates v, expanding any expressions it contains. It is
+// meant to be called from an ExpanderFunc to evaluate its arguments.
+func (c *ConfigParser) EvalValue(v interface{}) (interface{}, error) {
+	return c.evalValue(v)
 }
 
 func (c *ConfigParser) evalValue(v interface{}) (interface{}, error) {
@@ -150,16 +332,33 @@ func (c *ConfigParser) evalValue(v interface{}) (interface{}, error) {
 	if !ok {
 		return v, nil
 	}
+	if len(sl) == 0 {
+		return v, nil
+	}
 	if name, ok := sl[0].(string); ok {
-		if expander, ok := namedExpander(name); ok {
+		if expander, ok := c.namedExpander(name); ok {
+			c.exprSource = ""
 			newval, err := expander(c, sl[1:])
 			if err != nil {
-				return nil, err
+				if _, ok := err.(*ExpansionError); ok {
+					// Already reported by a nested expression.
+					return nil, err
+				}
+				return nil, &ExpansionError{
+					File:     c.CurrentFile(),
+					Path:     c.KeyPath(),
+					Expander: name,
+					Err:      err,
+				}
 			}
+			c.noteExpander(name, c.exprSource)
 			return newval, nil
 		}
 	}
+	path := c.keyPath
+	defer func() { c.keyPath = path }()
 	for i, oldval := range sl {
+		c.keyPath = appendPath(path, strconv.Itoa(i))
 		newval, err := c.evalValue(oldval)
 		if err != nil {
 			return nil, err
@@ -169,6 +368,15 @@ func (c *ConfigParser) evalValue(v interface{}) (interface{}, error) {
 	return v, nil
 }
 
+// appendPath returns a new slice holding path followed by key, so that
+// callers may keep path without it being overwritten.
+func appendPath(path []string, key string) []string {
+	p := make([]string, len(path)+1)
+	copy(p, path)
+	p[len(path)] = key
+	return p
+}
+
 // CheckTypes parses m and returns an error if it encounters a type or value
 // that is not supported by this package.
 func (c *ConfigParser) CheckTypes(m map[string]interface{}) error {
@@ -179,21 +387,24 @@ func (c *ConfigParser) CheckTypes(m map[string]interface{}) error {
 // that are found, unless testOnly is true.
 func (c *ConfigParser) evaluateExpressions(m map[string]interface{}, seenKeys []string, testOnly bool) error {
 	for k, ei := range m {
-		thisPath := append(seenKeys, k)
+		thisPath := appendPath(seenKeys, k)
 		switch subval := ei.(type) {
 		case string:
 			continue
 		case bool:
 			continue
-		case float64:
+		case json.Number, float64:
+			continue
+		case Secret:
 			continue
 		case []interface{}:
 			if len(subval) == 0 {
 				continue
 			}
+			c.keyPath = thisPath
 			evaled, err := c.evalValue(subval)
 			if err != nil {
-				return fmt.Errorf("%s: value error %v", strings.Join(thisPath, "."), err)
+				return err
 			}
 			if !testOnly {
 				m[k] = evaled
@@ -203,7 +414,11 @@ func (c *ConfigParser) evaluateExpressions(m map[string]interface{}, seenKeys []
 				return err
 			}
 		default:
-			return fmt.Errorf("%s: unhandled type %T", strings.Join(thisPath, "."), ei)
+			return &ExpansionError{
+				File: c.CurrentFile(),
+				Path: strings.Join(thisPath, "."),
+				Err:  fmt.Errorf("unhandled type %T", ei),
+			}
 		}
 	}
 	return nil
@@ -251,6 +466,11 @@ func (c *ConfigParser) expandEnv(v []interface{}) (interface{}, error) {
 		}
 		return val
 	})
+	var vars []string
+	for _, m := range envPattern.FindAllString(s, -1) {
+		vars = append(vars, m[2:len(m)-1])
+	}
+	c.exprSource = strings.Join(vars, ", ")
 	if wantsBool {
 		if expanded == "" {
 			return boolDefault, nil
@@ -269,8 +489,9 @@ func (c *ConfigParser) expandFile(v []interface{}) (exp interface{}, err error)
 		return "", fmt.Errorf("Included config does not exist: %v", v[0])
 	}
 	if exp, err = c.recursiveReadJSON(incPath); err != nil {
-		return "", fmt.Errorf("In file included from %s:\n%v",
+		return "", fmt.Errorf("In file included from %s:\n%w",
 			c.includeStack.Last(), err)
 	}
+	c.exprSource = incPath
 	return exp, nil
 }
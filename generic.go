@@ -0,0 +1,154 @@
+package jsoncfgo
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// Gettable is the set of types that Get, GetOr and MustGet read, those with
+// an accessor.
+type Gettable interface {
+	string | bool | int | int64 | uint | float64 | time.Duration | time.Time |
+		Obj | *url.URL | HostPort | netip.Addr | netip.Prefix | *regexp.Regexp |
+		[]string | []int64 | []float64 | []bool | []time.Duration | []Obj |
+		[]netip.Addr | []netip.Prefix |
+		map[string]string | map[string]int64 | map[string]Obj
+}
+
+// Get returns the value of the required key of jc as a T, like the
+// RequiredT method for that type, checked against the constraints cs. A
+// missing or bad value, or a violation of a constraint, is recorded as an
+// error of jc, to be reported by Validate.
+//
+// T may be any type with an accessor, as listed by Gettable.
+func Get[T Gettable](jc Obj, key string, cs ...Constraint[T]) T {
+	v, errs := read[T](jc, key, nil, cs)
+	for _, err := range errs {
+		jc.appendError(err)
+	}
+	return v
+}
+
+// GetOr is like Get, but returns def if key is missing, like the
+// OptionalT method for T. The constraints are not applied to def.
+func GetOr[T Gettable](jc Obj, key string, def T, cs ...Constraint[T]) T {
+	v, errs := read(jc, key, &def, cs)
+	for _, err := range errs {
+		jc.appendError(err)
+	}
+	return v
+}
+
+// MustGet is like Get, but panics with the first error instead of
+// recording it if the value is missing or bad or violates a constraint.
+func MustGet[T Gettable](jc Obj, key string, cs ...Constraint[T]) T {
+	v, errs := read[T](jc, key, nil, cs)
+	if len(errs) > 0 {
+		panic(errs[0])
+	}
+	return v
+}
+
+// read returns the value at key as a T, or *def if def is not nil and key
+// is missing, and the errors found reading and checking it, which are not
+// recorded on jc.
+func read[T Gettable](jc Obj, key string, def *T, cs []Constraint[T]) (T, []error) {
+	jc.noteKnownKey(key)
+	view := NewObj(make(map[string]interface{}, 1))
+	view.t.viewed, view.t.viewedKey = jc.t, key
+	ev, present := jc.m[key]
+	if present {
+		view.m[key] = ev
+	}
+	v := get(view, key, def)
+	errs := view.errorList()
+	if present && len(errs) == 0 {
+		errs = checkConstraints(view, key, v, cs)
+	}
+	return v, errs
+}
+
+// get returns the value at key as a T, or *def if def is not nil and key
+// is missing.
+func get[T Gettable](jc Obj, key string, def *T) T {
+	var v T
+	_, present := jc.m[key]
+	required := def == nil
+	// For the accessors that have no default value, such as the list
+	// accessors, listOr applies def.
+	listOr := func() {
+		if !present && def != nil {
+			v = *def
+		}
+	}
+	switch p := any(&v).(type) {
+	case *string:
+		*p = jc.string(key, any(def).(*string))
+	case *bool:
+		*p = jc.bool(key, any(def).(*bool))
+	case *int:
+		*p = jc.int(key, any(def).(*int))
+	case *int64:
+		*p = jc.int64(key, any(def).(*int64))
+	case *uint:
+		*p = jc.uint(key, any(def).(*uint))
+	case *float64:
+		*p = jc.float(key, any(def).(*float64))
+	case *time.Duration:
+		*p = jc.duration(key, any(def).(*time.Duration))
+	case *time.Time:
+		*p = jc.time(key, time.RFC3339, any(def).(*time.Time))
+	case *Obj:
+		*p = jc.obj(key, !required)
+		listOr()
+	case **url.URL:
+		*p = parseText(jc, key, "URL", any(def).(**url.URL), parseURL)
+	case *HostPort:
+		*p = parseText(jc, key, "host:port", any(def).(*HostPort), parseHostPort)
+	case *netip.Addr:
+		*p = parseText(jc, key, "IP address", any(def).(*netip.Addr), netip.ParseAddr)
+	case *netip.Prefix:
+		*p = parseText(jc, key, "CIDR prefix", any(def).(*netip.Prefix), netip.ParsePrefix)
+	case **regexp.Regexp:
+		*p = parseText(jc, key, "regexp", any(def).(**regexp.Regexp), regexp.Compile)
+	case *[]string:
+		*p = jc.requiredList(key, required)
+		listOr()
+	case *[]int64:
+		*p = jc.requiredIntList(key, required)
+		listOr()
+	case *[]float64:
+		*p = jc.requiredFloatList(key, required)
+		listOr()
+	case *[]bool:
+		*p = jc.requiredBoolList(key, required)
+		listOr()
+	case *[]time.Duration:
+		*p = jc.requiredDurationList(key, required)
+		listOr()
+	case *[]Obj:
+		*p = jc.requiredObjectList(key, required)
+		listOr()
+	case *[]netip.Addr:
+		*p = parseTextList(jc, key, "IP address", "list of IP addresses", required, netip.ParseAddr)
+		listOr()
+	case *[]netip.Prefix:
+		*p = parseTextList(jc, key, "CIDR prefix", "list of CIDR prefixes", required, netip.ParsePrefix)
+		listOr()
+	case *map[string]string:
+		*p = jc.requiredStringMap(key, required)
+		listOr()
+	case *map[string]int64:
+		*p = jc.requiredInt64Map(key, required)
+		listOr()
+	case *map[string]Obj:
+		*p = jc.requiredObjectMap(key, required)
+		listOr()
+	default:
+		panic(fmt.Sprintf("jsoncfgo: no accessor for type %T", v))
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	obj, err := ReadFile("testdata/lists.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := Get[float64](obj, "ratio"); g != 0.75 {
+		t.Errorf("ratio = %v; want 0.75", g)
+	}
+	if g, w := Get[[]bool](obj, "flags"), []bool{true, false, true}; !reflect.DeepEqual(g, w) {
+		t.Errorf("flags = %v; want %v", g, w)
+	}
+	if g := Get[[]time.Duration](obj, "intervals"); len(g) != 2 || g[1] != 250*time.Millisecond {
+		t.Errorf("intervals = %v", g)
+	}
+	ups := Get[[]Obj](obj, "upstreams")
+	if len(ups) != 2 || Get[string](ups[0], "name") != "a" || GetOr(ups[0], "weight", 1.0) != 1.0 {
+		t.Errorf("upstreams = %v", ups)
+	}
+	Get[int](ups[0], "port")
+	Get[int64](ups[1], "port")
+	Get[string](ups[1], "name")
+	ups[1].OptionalBool("bogus", false)
+	if g := GetOr(obj, "missing", "dflt"); g != "dflt" {
+		t.Errorf("missing = %q; want dflt", g)
+	}
+	if g, w := GetOr(obj, "missing", []string{"x"}), []string{"x"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("missing = %q; want %q", g, w)
+	}
+	if g := GetOr(obj, "missing", netip.MustParseAddr("::1")); g.String() != "::1" {
+		t.Errorf("missing = %v; want ::1", g)
+	}
+	if g := GetOr(obj, "missing", map[string]int64(nil)); g != nil {
+		t.Errorf("missing = %v; want nil", g)
+	}
+	if g := GetOr(obj, "missing", NewObj(map[string]interface{}{"a": 1})); len(g.Map()) != 1 {
+		t.Errorf("missing = %v; want the default", g)
+	}
+	GetOr(obj, "big", 0.0)
+	for _, k := range []string{"weights", "bad_weights", "bad_flags", "bad_intervals", "bad_upstreams"} {
+		obj.At(k).Exists()
+	}
+	errs := obj.errorList()
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v; want 1", errs)
+	}
+	var verr *ValueError
+	if !errors.As(errs[0], &verr) || verr.Key != "big" {
+		t.Errorf("error 0 = %v; want a range error for big", errs[0])
+	}
+	if err := obj.ValidateAll(); err == nil || strings.Contains(err.Error(), "Unknown key") {
+		t.Errorf("ValidateAll = %v; want no unknown keys", err)
+	}
+}
+
+func TestMustGet(t *testing.T) {
+	obj, err := ReadFile("testdata/lists.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := MustGet[float64](obj, "ratio"); g != 0.75 {
+		t.Errorf("ratio = %v; want 0.75", g)
+	}
+	for _, tt := range []struct {
+		name string
+		get  func()
+		kind error
+	}{
+		{"missing", func() { MustGet[string](obj, "missing") }, ErrMissingKey},
+		{"wrong type", func() { MustGet[bool](obj, "ratio") }, ErrWrongType},
+		{"bad value", func() { MustGet[[]bool](obj, "bad_flags") }, ErrBadValue},
+	} {
+		func() {
+			defer func() {
+				r := recover()
+				err, ok := r.(error)
+				if !ok {
+					t.Errorf("%s: recovered %v; want an error", tt.name, r)
+					return
+				}
+				if tt.kind != nil && !errors.Is(err, tt.kind) {
+					t.Errorf("%s: panic %v; want %v", tt.name, err, tt.kind)
+				}
+			}()
+			tt.get()
+		}()
+	}
+	if errs := obj.errorList(); len(errs) != 0 {
+		t.Errorf("MustGet recorded errors: %v", errs)
+	}
+}
@@ -17,6 +17,14 @@ Changes made:
 * Changed package name from jsonconfig to jsoncfgo
 * Added convenience functions:
 *  Bool, Int, Int64, IntList, List, Load, Object, String, requiredIntList
+* Integers are parsed from json.Number without loss of precision
+* Obj holds the config map and a tracker of its known keys and errors;
+*  added NewObj and Map
+* Obj is marshaled and formatted like the config map
+* Added ValidateAll
+* Errors are reported with exported error types
+* Load reports errors to ErrorHandler instead of exiting; added TryLoad
+* Added Float, RequiredIntList and OptionalIntList
 */
 
 // Package jsoncfgo defines a helper type for JSON objects to be
@@ -24,31 +32,99 @@ Changes made:
 package jsoncfgo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"sort"
 	"strconv"
 	"strings"
-	"log"
 )
 
+// ErrorHandler is called by Load with the error reading a config file, and
+// by a Watcher with the errors of its reloads if its OnError field is nil.
+// It may be replaced, before the config is loaded, to use another logger
+// or handle errors differently. The default logs the error with the
+// standard logger. No function of this package exits the process.
+var ErrorHandler = func(err error) {
+	log.Printf("jsoncfgo: %v", err)
+}
+
 // Load calls ReadFile to read json config data from the file specified by configPath
-// If an error occurs, it will log the error.
+// If an error occurs, it is passed to ErrorHandler, and Load returns an empty
+// Obj whose Validate method reports the error.
 func Load(configPath string) Obj {
+	conf, err := TryLoad(configPath)
+	if err != nil {
+		ErrorHandler(err)
+	}
+	return conf
+}
+
+// TryLoad is like Load, but returns the error instead of passing it to
+// ErrorHandler. On error the returned Obj is empty, not nil, and its
+// Validate method reports the error, so that it may still be used to
+// fall back to default values.
+func TryLoad(configPath string) (Obj, error) {
 	conf, err := ReadFile(configPath)
 	if err != nil {
-		log.Fatal(err.Error())
+		conf = NewObj(nil)
+		conf.appendError(err)
+		return conf, err
 	}
-	return Obj(conf)
+	return conf, nil
+}
+
+// Obj is a JSON configuration object. It holds the object decoded from
+// the config, and the tracker of the keys read by its accessors and the
+// errors they found, which Validate reports. Obj is a struct rather than
+// a map so that reading a config never modifies it; use NewObj to create
+// one from a map. The zero Obj is an empty config that tracks nothing;
+// its Validate method reports ErrNoTracker.
+type Obj struct {
+	m map[string]interface{}
+	t *tracker
 }
 
-// Obj is a JSON configuration map.
-type Obj map[string]interface{}
+// ErrNoTracker is returned by the Validate and ValidateAll methods of an
+// Obj that was not created by ReadFile, Load, NewObj or an accessor, such
+// as the zero Obj, and so has not recorded its known keys and errors.
+var ErrNoTracker = errors.New("jsoncfgo: Obj has no tracker; create it with ReadFile, Load or NewObj")
+
+// NewObj returns the config holding m, a JSON object decoded as by
+// encoding/json into a map[string]interface{}, with no known keys or
+// errors. Its accessors never modify m.
+func NewObj(m map[string]interface{}) Obj {
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	return Obj{m: m, t: newTracker()}
+}
+
+// Map returns the object held by jc. It must not be modified.
+func (jc Obj) Map() map[string]interface{} {
+	return jc.m
+}
+
+// MarshalJSON encodes the object held by jc.
+func (jc Obj) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jc.m)
+}
+
+// Format formats the object held by jc like a map.
+func (jc Obj) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, fmt.FormatString(f, verb), jc.m)
+}
 
 // Reads json config data from the specified open file, expanding
 // all expressions
 func ReadFile(configPath string) (Obj, error) {
 	var c ConfigParser
-	return c.ReadFile(configPath)
+	m, err := c.ReadFile(configPath)
+	if err != nil {
+		return Obj{}, err
+	}
+	return NewObj(m), nil
 }
 
 func (jc Obj) RequiredObject(key string) Obj {
@@ -66,20 +142,26 @@ func (jc Obj) Object(key string) Obj {
 
 func (jc Obj) obj(key string, optional bool) Obj {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if optional {
-			return make(Obj)
+			return NewObj(nil)
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (object)", key))
-		return make(Obj)
+		jc.appendError(missingKey(key, "object"))
+		return NewObj(nil)
 	}
 	m, ok := ei.(map[string]interface{})
 	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be an object, not %T", key, ei))
-		return make(Obj)
+		jc.appendError(wrongType(key, -1, "an object", ei))
+		return NewObj(nil)
 	}
-	return Obj(m)
+	return jc.child(key, m)
+}
+
+// child returns the nested object m at key, tracked by the child of the
+// tracker of jc so that ValidateAll checks its keys.
+func (jc Obj) child(key string, m map[string]interface{}) Obj {
+	return Obj{m: m, t: jc.t.child(key)}
 }
 
 func (jc Obj) RequiredString(key string) string {
@@ -106,17 +188,17 @@ func (jc Obj) String(key string, args ...interface{}) string {
 
 func (jc Obj) string(key string, def *string) string {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if def != nil {
 			return *def
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (string)", key))
+		jc.appendError(missingKey(key, "string"))
 		return ""
 	}
 	s, ok := ei.(string)
 	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a string", key))
+		jc.appendError(wrongType(key, -1, "a string", ei))
 		return ""
 	}
 	return s
@@ -137,12 +219,12 @@ func (jc Obj) StringOrObject(key string) interface{} {
 
 func (jc Obj) stringOrObject(key string, required bool) interface{} {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if !required {
 			return nil
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (string or object)", key))
+		jc.appendError(missingKey(key, "string or object"))
 		return ""
 	}
 	if _, ok := ei.(map[string]interface{}); ok {
@@ -151,7 +233,7 @@ func (jc Obj) stringOrObject(key string, required bool) interface{} {
 	if _, ok := ei.(string); ok {
 		return ei
 	}
-	jc.appendError(fmt.Errorf("Expected config key %q to be a string or object", key))
+	jc.appendError(wrongType(key, -1, "a string or object", ei))
 	return ""
 }
 
@@ -179,12 +261,12 @@ func (jc Obj) Bool(key string, args ...interface{}) bool {
 
 func (jc Obj) bool(key string, def *bool) bool {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if def != nil {
 			return *def
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (boolean)", key))
+		jc.appendError(missingKey(key, "boolean"))
 		return false
 	}
 	switch v := ei.(type) {
@@ -193,11 +275,11 @@ func (jc Obj) bool(key string, def *bool) bool {
 	case string:
 		b, err := strconv.ParseBool(v)
 		if err != nil {
-			jc.appendError(fmt.Errorf("Config key %q has bad boolean format %q", key, v))
+			jc.appendError(jc.badValue(key, -1, v, "boolean", err))
 		}
 		return b
 	default:
-		jc.appendError(fmt.Errorf("Expected config key %q to be a boolean", key))
+		jc.appendError(wrongType(key, -1, "a boolean", ei))
 		return false
 	}
 }
@@ -226,23 +308,22 @@ func (jc Obj) Int(key string, args ...interface{}) int {
 
 func (jc Obj) int(key string, def *int) int {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if def != nil {
 			return *def
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (integer)", key))
+		jc.appendError(missingKey(key, "integer"))
 		return 0
 	}
-	b, ok := ei.(float64)
-	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a number", key))
+	n, err := toInt64(ei, strconv.IntSize)
+	if err != nil {
+		jc.numberError(key, ei, "int", err)
 		return 0
 	}
-	return int(b)
+	return int(n)
 }
 
-
 func (jc Obj) RequiredUint(key string) uint {
 	return jc.uint(key, nil)
 }
@@ -269,24 +350,22 @@ func (jc Obj) Uint(key string, args ...interface{}) uint {
 
 func (jc Obj) uint(key string, def *uint) uint {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if def != nil {
 			return *def
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (integer)", key))
+		jc.appendError(missingKey(key, "integer"))
 		return 0
 	}
-	b, ok := ei.(float64)
-	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a number", key))
+	n, err := toUint64(ei, strconv.IntSize)
+	if err != nil {
+		jc.numberError(key, ei, "uint", err)
 		return 0
 	}
-	return uint(b)
+	return uint(n)
 }
 
-
-
 func (jc Obj) RequiredInt64(key string) int64 {
 	return jc.int64(key, nil)
 }
@@ -313,22 +392,63 @@ func (jc Obj) Int64(key string, args ...interface{}) int64 {
 
 func (jc Obj) int64(key string, def *int64) int64 {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if def != nil {
 			return *def
 		}
-		jc.appendError(fmt.Errorf("Missing required config key %q (integer)", key))
+		jc.appendError(missingKey(key, "integer"))
 		return 0
 	}
-	b, ok := ei.(float64)
-	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a number", key))
+	n, err := toInt64(ei, 64)
+	if err != nil {
+		jc.numberError(key, ei, "int64", err)
 		return 0
 	}
-	return int64(b)
+	return n
+}
+
+func (jc Obj) RequiredFloat(key string) float64 {
+	return jc.float(key, nil)
 }
 
+func (jc Obj) OptionalFloat(key string, def float64) float64 {
+	return jc.float(key, &def)
+}
+
+// Float is an Optional Float
+func (jc Obj) Float(key string, args ...interface{}) float64 {
+	var def float64
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case int:
+			def = float64(t)
+		case float64:
+			def = t
+		default:
+			panic(fmt.Sprintf("ERROR - Invalid argument (%v).  Must be a float64.", arg))
+		}
+	}
+	return jc.float(key, &def)
+}
+
+func (jc Obj) float(key string, def *float64) float64 {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if def != nil {
+			return *def
+		}
+		jc.appendError(missingKey(key, "number"))
+		return 0
+	}
+	f, err := toFloat64(ei)
+	if err != nil {
+		jc.numberError(key, ei, "float64", err)
+		return 0
+	}
+	return f
+}
 
 func (jc Obj) RequiredList(key string) []string {
 	return jc.requiredList(key, true)
@@ -337,7 +457,7 @@ func (jc Obj) RequiredList(key string) []string {
 // List accepts and optional parameter of type []string
 func (jc Obj) List(key string, args ...interface{}) []string {
 	ret := jc.requiredList(key, true)
-	if ret == nil {
+	if ret == nil && len(args) > 0 {
 		ret = args[0].([]string)
 	}
 	return ret
@@ -346,7 +466,7 @@ func (jc Obj) List(key string, args ...interface{}) []string {
 // List accepts and optional parameter of type []int64
 func (jc Obj) IntList(key string, args ...interface{}) []int64 {
 	ret := jc.requiredIntList(key, true)
-	if ret == nil {
+	if ret == nil && len(args) > 0 {
 		ret = args[0].([]int64)
 	}
 	return ret
@@ -356,25 +476,34 @@ func (jc Obj) OptionalList(key string) []string {
 	return jc.requiredList(key, false)
 }
 
+func (jc Obj) RequiredIntList(key string) []int64 {
+	return jc.requiredIntList(key, true)
+}
+
+func (jc Obj) OptionalIntList(key string) []int64 {
+	return jc.requiredIntList(key, false)
+}
+
 func (jc Obj) requiredList(key string, required bool) []string {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if required {
-			jc.appendError(fmt.Errorf("Missing required config key %q (list of strings)", key))
+			jc.appendError(missingKey(key, "list of strings"))
 		}
 		return nil
 	}
 	eil, ok := ei.([]interface{})
 	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a list, not %T", key, ei))
+		jc.appendError(wrongType(key, -1, "a list", ei))
 		return nil
 	}
+	eil = revealList(eil)
 	sl := make([]string, len(eil))
 	for i, ei := range eil {
 		s, ok := ei.(string)
 		if !ok {
-			jc.appendError(fmt.Errorf("Expected config key %q index %d to be a string, not %T", key, i, ei))
+			jc.appendError(wrongType(key, i, "a string", ei))
 			return nil
 		}
 		sl[i] = s
@@ -384,52 +513,68 @@ func (jc Obj) requiredList(key string, required bool) []string {
 
 func (jc Obj) requiredIntList(key string, required bool) []int64 {
 	jc.noteKnownKey(key)
-	ei, ok := jc[key]
+	ei, ok := jc.lookup(key)
 	if !ok {
 		if required {
-			jc.appendError(fmt.Errorf("Missing required config key %q (list of ints)", key))
+			jc.appendError(missingKey(key, "list of ints"))
 		}
 		return nil
 	}
 	eil, ok := ei.([]interface{})
 	if !ok {
-		jc.appendError(fmt.Errorf("Expected config key %q to be a list, not %T", key, ei))
+		jc.appendError(wrongType(key, -1, "a list", ei))
 		return nil
 	}
+	eil = revealList(eil)
 	sl := make([]int64, len(eil))
 	for i, ei := range eil {
-		sl[i] = int64(ei.(float64))
+		n, err := toInt64(ei, 64)
+		if err != nil {
+			jc.numberIndexError(key, i, ei, "int64", err)
+			return nil
+		}
+		sl[i] = n
 	}
 	return sl
 }
 
+// noteKnownKey and appendError record their information in the tracker
+// of jc rather than in jc itself, so that reading a config never modifies
+// it. The zero Obj has no tracker, and then nothing is recorded.
 func (jc Obj) noteKnownKey(key string) {
-	_, ok := jc["_knownkeys"]
-	if !ok {
-		jc["_knownkeys"] = make(map[string]bool)
+	if jc.t != nil {
+		jc.t.noteKnownKey(key)
 	}
-	jc["_knownkeys"].(map[string]bool)[key] = true
+}
+
+// lookup returns the value at key, revealing it if it is a Secret.
+func (jc Obj) lookup(key string) (interface{}, bool) {
+	v, ok := jc.m[key]
+	return reveal(v), ok
 }
 
 func (jc Obj) appendError(err error) {
-	ei, ok := jc["_errors"]
-	if ok {
-		jc["_errors"] = append(ei.([]error), err)
-	} else {
-		jc["_errors"] = []error{err}
+	if jc.t != nil {
+		jc.t.appendError(err)
+	}
+}
+
+func (jc Obj) isKnownKey(key string) bool {
+	return jc.t != nil && jc.t.isKnown(key)
+}
+
+func (jc Obj) errorList() []error {
+	if jc.t == nil {
+		return nil
 	}
+	return jc.t.errorList()
 }
 
 // UnknownKeys returns the keys from the config that have not yet been discovered by one of the RequiredT or OptionalT calls.
 func (jc Obj) UnknownKeys() []string {
-	ei, ok := jc["_knownkeys"]
-	var known map[string]bool
-	if ok {
-		known = ei.(map[string]bool)
-	}
 	var unknown []string
-	for k, _ := range jc {
-		if ok && known[k] {
+	for k := range jc.m {
+		if jc.isKnownKey(k) {
 			continue
 		}
 		if strings.HasPrefix(k, "_") {
@@ -443,23 +588,70 @@ func (jc Obj) UnknownKeys() []string {
 	return unknown
 }
 
+// Validate returns the errors recorded by the accessors of jc and one for
+// each of its unknown keys. The zero Obj cannot record errors, so its
+// Validate returns ErrNoTracker.
 func (jc Obj) Validate() error {
+	if jc.t == nil {
+		return ErrNoTracker
+	}
 	unknown := jc.UnknownKeys()
 	for _, k := range unknown {
-		jc.appendError(fmt.Errorf("Unknown key %q", k))
+		jc.appendError(&UnknownKeyError{Key: k})
 	}
+	return joinErrors(jc.errorList())
+}
 
-	ei, ok := jc["_errors"]
-	if !ok {
-		return nil
+// ValidateAll is like Validate, but also validates every object nested in
+// jc that was read with one of the RequiredT or OptionalT calls, whether
+// it was written in the same file or included with _fileobj, and the
+// objects in its lists. The keys of the errors of nested objects are
+// their full dotted paths. Unlike Validate, ValidateAll does not record the unknown keys as
+// errors of their objects, so it may be called repeatedly.
+func (jc Obj) ValidateAll() error {
+	if jc.t == nil {
+		return ErrNoTracker
+	}
+	var errs []error
+	jc.validateTree(nil, &errs)
+	return joinErrors(errs)
+}
+
+func (jc Obj) validateTree(path []string, errs *[]error) {
+	for _, err := range jc.errorList() {
+		if _, ok := err.(*UnknownKeyError); ok {
+			// Recorded by Validate; reported again below.
+			continue
+		}
+		*errs = append(*errs, atPath(path, err))
+	}
+	for _, k := range jc.UnknownKeys() {
+		*errs = append(*errs, atPath(path, &UnknownKeyError{Key: k}))
 	}
-	errList := ei.([]error)
-	if len(errList) == 1 {
-		return errList[0]
+	keys := make([]string, 0, len(jc.m))
+	for k := range jc.m {
+		if jc.isKnownKey(k) {
+			keys = append(keys, k)
+		}
 	}
-	strs := make([]string, 0)
-	for _, v := range errList {
-		strs = append(strs, v.Error())
+	sort.Strings(keys)
+	for _, k := range keys {
+		validateValue(jc.m[k], jc.t.lookupChild(k), appendPath(path, k), errs)
+	}
+}
+
+// validateValue validates the objects read with an accessor in v, whose
+// tracker is t.
+func validateValue(v interface{}, t *tracker, path []string, errs *[]error) {
+	if t == nil {
+		return
+	}
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		Obj{m: tv, t: t}.validateTree(path, errs)
+	case []interface{}:
+		for i, ev := range tv {
+			validateValue(ev, t.lookupChild(strconv.Itoa(i)), appendPath(path, strconv.Itoa(i)), errs)
+		}
 	}
-	return fmt.Errorf("Multiple errors: " + strings.Join(strs, ", "))
 }
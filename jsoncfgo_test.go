@@ -17,10 +17,14 @@ limitations under the License.
 package jsoncfgo
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+
 	u "github.com/go-goodies/go_utils"
 )
 
@@ -131,3 +135,299 @@ func TestUint(t *testing.T) {
 	}
 }
 
+func TestRegisterExpander(t *testing.T) {
+	os.Setenv("TEST_BAR", "bar")
+	var c ConfigParser
+	var paths []string
+	c.RegisterExpander("_upper", func(c *ConfigParser, v []interface{}) (interface{}, error) {
+		paths = append(paths, c.KeyPath())
+		if len(v) != 1 {
+			return nil, fmt.Errorf("_upper expected 1 arg, got %d", len(v))
+		}
+		ev, err := c.EvalValue(v[0])
+		if err != nil {
+			return nil, err
+		}
+		s, ok := ev.(string)
+		if !ok {
+			return nil, fmt.Errorf("_upper expected a string, got %T", ev)
+		}
+		return strings.ToUpper(s), nil
+	})
+	m, err := c.ReadFile("testdata/expander.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := NewObj(m)
+	nested := obj.RequiredObject("nested")
+	if g, e := obj.RequiredString("plain"), "VALUE"; g != e {
+		t.Errorf("plain = %q; want %q", g, e)
+	}
+	if g, e := nested.RequiredString("env"), "BAR"; g != e {
+		t.Errorf("nested.env = %q; want %q", g, e)
+	}
+	if g, e := nested.RequiredList("list"), []string{"a", "B"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("nested.list = %q; want %q", g, e)
+	}
+	sort.Strings(paths)
+	if e := []string{"nested.env", "nested.list.1", "plain"}; !reflect.DeepEqual(paths, e) {
+		t.Errorf("key paths = %q; want %q", paths, e)
+	}
+
+	// Without the parser-specific registration the expression is
+	// left untouched.
+	obj, err = ReadFile("testdata/expander.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := obj.RequiredList("plain"); !reflect.DeepEqual(g, []string{"_upper", "value"}) {
+		t.Errorf("unregistered plain = %q", g)
+	}
+}
+
+func TestExpanderError(t *testing.T) {
+	var c ConfigParser
+	c.RegisterExpander("_upper", func(c *ConfigParser, v []interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	_, err := c.ReadFile("testdata/expander.json")
+	if err == nil {
+		t.Fatal("expected an expander error")
+	}
+	if !strings.Contains(err.Error(), ": value error boom") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRegisterExpanderPanics(t *testing.T) {
+	fn := func(c *ConfigParser, v []interface{}) (interface{}, error) { return nil, nil }
+	for _, name := range []string{"", "_", "upper", "_env"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterExpander(%q) did not panic", name)
+				}
+			}()
+			RegisterExpander(name, fn)
+		}()
+	}
+}
+
+func TestIntegerPrecision(t *testing.T) {
+	obj, err := ReadFile("testdata/numbers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := obj.RequiredInt64("big"), int64(9007199254740993); g != e {
+		t.Errorf("big = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredInt64("maxint64"), int64(9223372036854775807); g != e {
+		t.Errorf("maxint64 = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredInt64("minint64"), int64(-9223372036854775808); g != e {
+		t.Errorf("minint64 = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredInt("exp"), 1500; g != e {
+		t.Errorf("exp = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredUint("maxuint"), uint(18446744073709551615); g != e {
+		t.Errorf("maxuint = %d; want %d", g, e)
+	}
+	if g, e := obj.IntList("list"), []int64{9007199254740993, 1, -2}; !reflect.DeepEqual(g, e) {
+		t.Errorf("list = %v; want %v", g, e)
+	}
+	if err := obj.Validate(); err == nil || !strings.Contains(err.Error(), "Unknown key") {
+		t.Errorf("expected only unknown key errors, got %v", err)
+	}
+}
+
+func TestIntegerConversionErrors(t *testing.T) {
+	obj, err := ReadFile("testdata/numbers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		get  func() interface{}
+		want string
+	}{
+		{func() interface{} { return obj.RequiredInt64("overflow") }, `"overflow" value 9223372036854775808 cannot be converted to int64: out of range`},
+		{func() interface{} { return obj.RequiredInt64("huge") }, `"huge" value 1e400 cannot be converted to int64: out of range`},
+		{func() interface{} { return obj.RequiredInt("fraction") }, `"fraction" value 2.5 cannot be converted to int: not a whole number`},
+		{func() interface{} { return obj.RequiredUint("negative") }, `"negative" value -1 cannot be converted to uint: negative value for an unsigned type`},
+		{func() interface{} { return obj.requiredIntList("badlist", true) }, `"badlist" index 1 value 2.5 cannot be converted to int64: not a whole number`},
+		{func() interface{} { return obj.requiredIntList("strlist", true) }, `"strlist" index 1 to be a number, not string`},
+	}
+	for _, tt := range tests {
+		tt.get()
+	}
+	for _, k := range []string{"big", "maxint64", "minint64", "exp", "maxuint", "list"} {
+		obj.noteKnownKey(k)
+	}
+	err = obj.Validate()
+	if err == nil {
+		t.Fatal("expected conversion errors")
+	}
+	for _, tt := range tests {
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("error %q does not contain %q", err, tt.want)
+		}
+	}
+	if strings.Contains(err.Error(), "Unknown key") {
+		t.Errorf("unexpected unknown key error in %q", err)
+	}
+}
+
+func TestRelaxed(t *testing.T) {
+	if _, err := ReadFile("testdata/relaxed.json"); err == nil {
+		t.Fatal("expected strict parsing to reject comments")
+	}
+	c := ConfigParser{Relaxed: true}
+	m, err := c.ReadFile("testdata/relaxed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := NewObj(m)
+	if g, e := obj.RequiredString("host"), "localhost"; g != e {
+		t.Errorf("host = %q; want %q", g, e)
+	}
+	if g, e := obj.RequiredInt("port"), 8080; g != e {
+		t.Errorf("port = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredString("$url"), "http://example.com/a//b/*c*/"; g != e {
+		t.Errorf("$url = %q; want %q", g, e)
+	}
+	if g, e := obj.RequiredList("list"), []string{"a", "b"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("list = %q; want %q", g, e)
+	}
+	if !obj.RequiredObject("nested").RequiredBool("inner_key") {
+		t.Error("nested.inner_key = false; want true")
+	}
+	if err := obj.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRelaxedSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		file, want string
+	}{
+		{"testdata/relaxed_bad.json", "Error at line 4, column 13"},
+		{"testdata/relaxed_unterminated.json", "Error at line 3, column 3"},
+		{"testdata/relaxed_empty_list.json", "Error at line 2, column 13"},
+		{"testdata/relaxed_empty_object.json", "Error at line 3, column 12"},
+	}
+	for _, tt := range tests {
+		c := ConfigParser{Relaxed: true}
+		_, err := c.ReadFile(tt.file)
+		if err == nil {
+			t.Errorf("%s: expected a syntax error", tt.file)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not contain %q", tt.file, err, tt.want)
+		}
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	obj, err := ReadFile("testdata/validate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.RequiredString("name")
+	db := obj.RequiredObject("db")
+	db.RequiredString("host")
+	db.RequiredInt("port")
+	obj.RequiredObject("inc").RequiredString("key")
+	for _, s := range obj.RequiredObjectList("servers") {
+		s.RequiredString("host")
+	}
+
+	if err := obj.Validate(); err == nil || strings.Contains(err.Error(), "db") {
+		t.Errorf("Validate = %v; want only top-level errors", err)
+	}
+	err = obj.ValidateAll()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`Unknown key "typo"`,
+		`Unknown key "unread"`,
+		`Expected config key "db.port" to be a number, not string`,
+		`Unknown key "db.extra"`,
+		`Unknown key "inc.stray"`,
+		`Unknown key "servers.0.bogus"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "unread.anything") {
+		t.Errorf("error %q reports keys of an object never read", err)
+	}
+	if err2 := obj.ValidateAll(); err2 == nil || err2.Error() != err.Error() {
+		t.Errorf("second ValidateAll = %v; want %v", err2, err)
+	}
+}
+
+func TestValidateAllUnreadObject(t *testing.T) {
+	obj, err := ReadFile("testdata/validate.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.OptionalObject("unread")
+	err = obj.ValidateAll()
+	if err == nil || !strings.Contains(err.Error(), `Unknown key "unread.anything"`) {
+		t.Errorf("ValidateAll = %v; want the unknown key of an object never read by key", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	var handled []error
+	defer func(h func(error)) { ErrorHandler = h }(ErrorHandler)
+	ErrorHandler = func(err error) { handled = append(handled, err) }
+
+	obj := Load("testdata/does-not-exist.json")
+	if len(obj.Map()) != 0 {
+		t.Errorf("Load of a missing file = %v; want an empty Obj", obj)
+	}
+	if len(handled) != 1 {
+		t.Fatalf("ErrorHandler called %d times; want 1", len(handled))
+	}
+	if g := obj.OptionalString("host", "localhost"); g != "localhost" {
+		t.Errorf("host = %q; want the default", g)
+	}
+	if err := obj.Validate(); err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Validate = %v; want the load error", err)
+	}
+
+	obj, err := TryLoad("testdata/loop1.json")
+	var cerr *IncludeCycleError
+	if !errors.As(err, &cerr) {
+		t.Errorf("TryLoad error = %v; want an include cycle", err)
+	}
+	if obj.Map() == nil {
+		t.Error("TryLoad returned a nil map")
+	}
+	if len(handled) != 1 {
+		t.Errorf("TryLoad called ErrorHandler")
+	}
+
+	obj = Load("testdata/include1.json")
+	if len(handled) != 1 || obj.RequiredObject("two").RequiredString("key") != "value" {
+		t.Errorf("Load of a valid file failed: %v", handled)
+	}
+}
+
+func TestListWithoutDefault(t *testing.T) {
+	obj := Obj{}
+	if l := obj.List("missing"); l != nil {
+		t.Errorf("List = %q; want nil", l)
+	}
+	if l := obj.IntList("missing"); l != nil {
+		t.Errorf("IntList = %v; want nil", l)
+	}
+	if err := obj.Validate(); err == nil {
+		t.Error("expected missing key errors")
+	}
+}
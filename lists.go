@@ -0,0 +1,145 @@
+package jsoncfgo
+
+import (
+	"strconv"
+	"time"
+)
+
+func (jc Obj) RequiredFloatList(key string) []float64 {
+	return jc.requiredFloatList(key, true)
+}
+
+func (jc Obj) OptionalFloatList(key string) []float64 {
+	return jc.requiredFloatList(key, false)
+}
+
+func (jc Obj) RequiredBoolList(key string) []bool {
+	return jc.requiredBoolList(key, true)
+}
+
+func (jc Obj) OptionalBoolList(key string) []bool {
+	return jc.requiredBoolList(key, false)
+}
+
+func (jc Obj) RequiredDurationList(key string) []time.Duration {
+	return jc.requiredDurationList(key, true)
+}
+
+func (jc Obj) OptionalDurationList(key string) []time.Duration {
+	return jc.requiredDurationList(key, false)
+}
+
+// RequiredObjectList returns the list of objects at key. Like the objects
+// returned by RequiredObject, the elements are checked by ValidateAll.
+func (jc Obj) RequiredObjectList(key string) []Obj {
+	return jc.requiredObjectList(key, true)
+}
+
+func (jc Obj) OptionalObjectList(key string) []Obj {
+	return jc.requiredObjectList(key, false)
+}
+
+// list returns the list at key, recording an error if it is not a list or
+// is missing and required.
+func (jc Obj) list(key string, required bool, typ string) ([]interface{}, bool) {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if required {
+			jc.appendError(missingKey(key, typ))
+		}
+		return nil, false
+	}
+	eil, ok := ei.([]interface{})
+	if !ok {
+		jc.appendError(wrongType(key, -1, "a list", ei))
+		return nil, false
+	}
+	return revealList(eil), true
+}
+
+func (jc Obj) requiredFloatList(key string, required bool) []float64 {
+	eil, ok := jc.list(key, required, "list of numbers")
+	if !ok {
+		return nil
+	}
+	fl := make([]float64, len(eil))
+	for i, ei := range eil {
+		f, err := toFloat64(ei)
+		if err != nil {
+			jc.numberIndexError(key, i, ei, "float64", err)
+			return nil
+		}
+		fl[i] = f
+	}
+	return fl
+}
+
+func (jc Obj) requiredBoolList(key string, required bool) []bool {
+	eil, ok := jc.list(key, required, "list of booleans")
+	if !ok {
+		return nil
+	}
+	bl := make([]bool, len(eil))
+	for i, ei := range eil {
+		switch v := ei.(type) {
+		case bool:
+			bl[i] = v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				jc.appendError(jc.badValue(key, i, v, "boolean", err))
+				return nil
+			}
+			bl[i] = b
+		default:
+			jc.appendError(wrongType(key, i, "a boolean", ei))
+			return nil
+		}
+	}
+	return bl
+}
+
+func (jc Obj) requiredDurationList(key string, required bool) []time.Duration {
+	eil, ok := jc.list(key, required, "list of durations")
+	if !ok {
+		return nil
+	}
+	dl := make([]time.Duration, len(eil))
+	for i, ei := range eil {
+		s, ok := ei.(string)
+		if !ok {
+			jc.appendError(wrongType(key, i, "a duration string", ei))
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			jc.appendError(jc.badValue(key, i, s, "duration", durationError(s, err)))
+			return nil
+		}
+		dl[i] = d
+	}
+	return dl
+}
+
+func (jc Obj) requiredObjectList(key string, required bool) []Obj {
+	eil, ok := jc.list(key, required, "list of objects")
+	if !ok {
+		return nil
+	}
+	ol := make([]Obj, len(eil))
+	for i, ei := range eil {
+		m, ok := ei.(map[string]interface{})
+		if !ok {
+			jc.appendError(wrongType(key, i, "an object", ei))
+			return nil
+		}
+		ol[i] = Obj{m: m}
+	}
+	// Track the objects, so that ValidateAll checks their keys.
+	lt := jc.t.child(key)
+	for i := range ol {
+		ol[i].t = lt.child(strconv.Itoa(i))
+	}
+	return ol
+}
@@ -0,0 +1,96 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFloat(t *testing.T) {
+	obj, err := ReadFile("testdata/lists.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := obj.RequiredFloat("ratio"); g != 0.75 {
+		t.Errorf("ratio = %v; want 0.75", g)
+	}
+	if g := obj.Float("missing", 1.5); g != 1.5 {
+		t.Errorf("missing = %v; want 1.5", g)
+	}
+	if g := obj.OptionalFloat("missing", 2); g != 2 {
+		t.Errorf("missing = %v; want 2", g)
+	}
+	obj.RequiredFloat("big")
+	obj.RequiredFloat("flags")
+	var verr *ValueError
+	var terr *TypeError
+	errs := obj.errorList()
+	if len(errs) != 2 || !errors.As(errs[0], &verr) || verr.Err != errRange || !errors.As(errs[1], &terr) {
+		t.Errorf("errors = %v; want a range error and a type error", errs)
+	}
+}
+
+func TestTypedLists(t *testing.T) {
+	obj, err := ReadFile("testdata/lists.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := obj.RequiredFloatList("weights"), []float64{1, 2.5, -300}; !reflect.DeepEqual(g, w) {
+		t.Errorf("weights = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredBoolList("flags"), []bool{true, false, true}; !reflect.DeepEqual(g, w) {
+		t.Errorf("flags = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredDurationList("intervals"), []time.Duration{time.Second, 250 * time.Millisecond}; !reflect.DeepEqual(g, w) {
+		t.Errorf("intervals = %v; want %v", g, w)
+	}
+	ups := obj.RequiredObjectList("upstreams")
+	if len(ups) != 2 {
+		t.Fatalf("upstreams = %v; want 2 objects", ups)
+	}
+	for _, up := range ups {
+		up.RequiredString("name")
+		up.RequiredInt("port")
+	}
+	if l := obj.OptionalFloatList("missing"); l != nil {
+		t.Errorf("missing = %v; want nil", l)
+	}
+	if l := obj.OptionalObjectList("missing"); l != nil {
+		t.Errorf("missing = %v; want nil", l)
+	}
+	obj.OptionalBoolList("missing")
+	obj.OptionalDurationList("missing")
+	obj.RequiredFloat("ratio")
+	obj.RequiredFloat("big")
+
+	obj.RequiredFloatList("bad_weights")
+	obj.RequiredBoolList("bad_flags")
+	obj.RequiredDurationList("bad_intervals")
+	obj.RequiredObjectList("bad_upstreams")
+	obj.RequiredObjectList("nowhere")
+	obj.RequiredBoolList("ratio")
+
+	var merr MultiError
+	if err := obj.ValidateAll(); !errors.As(err, &merr) {
+		t.Fatalf("ValidateAll = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Config key "big" value 1e400 cannot be converted to float64: out of range`,
+		`Expected config key "bad_weights" index 1 to be a number, not string`,
+		`Config key "bad_flags" index 1 value "maybe" cannot be converted to boolean: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		`Expected config key "bad_intervals" index 1 to be a duration string, not json.Number`,
+		`Expected config key "bad_upstreams" index 1 to be an object, not string`,
+		`Missing required config key "nowhere" (list of objects)`,
+		`Expected config key "ratio" to be a list, not json.Number`,
+		`Unknown key "upstreams.1.bogus"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package jsoncfgo
+
+import "sort"
+
+// The map accessors read objects whose keys are arbitrary names, such as
+// labels or per-tenant settings, rather than config keys. All the keys of
+// such an object are marked as known, so Validate and ValidateAll do not
+// report them. Every value of the wrong type is reported with its full
+// path, key.name, and the map is then nil.
+
+func (jc Obj) RequiredStringMap(key string) map[string]string {
+	return jc.requiredStringMap(key, true)
+}
+
+func (jc Obj) OptionalStringMap(key string) map[string]string {
+	return jc.requiredStringMap(key, false)
+}
+
+func (jc Obj) RequiredInt64Map(key string) map[string]int64 {
+	return jc.requiredInt64Map(key, true)
+}
+
+func (jc Obj) OptionalInt64Map(key string) map[string]int64 {
+	return jc.requiredInt64Map(key, false)
+}
+
+// RequiredObjectMap returns the objects that are the values of the object
+// at key. Unlike the keys of the map, the keys of these objects are
+// checked by ValidateAll like those of any object read with RequiredObject.
+func (jc Obj) RequiredObjectMap(key string) map[string]Obj {
+	return jc.requiredObjectMap(key, true)
+}
+
+func (jc Obj) OptionalObjectMap(key string) map[string]Obj {
+	return jc.requiredObjectMap(key, false)
+}
+
+// mapEntries returns the keys of the object at key in order, and the
+// object, recording an error if it is not an object or is missing and
+// required. All the keys of the object are marked as known.
+func (jc Obj) mapEntries(key string, required bool, typ string) ([]string, Obj) {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if required {
+			jc.appendError(missingKey(key, typ))
+		}
+		return nil, Obj{}
+	}
+	m, ok := ei.(map[string]interface{})
+	if !ok {
+		jc.appendError(wrongType(key, -1, "an object", ei))
+		return nil, Obj{}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	obj := jc.child(key, m)
+	for _, k := range keys {
+		obj.noteKnownKey(k)
+	}
+	return keys, obj
+}
+
+func (jc Obj) requiredStringMap(key string, required bool) map[string]string {
+	keys, obj := jc.mapEntries(key, required, "map of strings")
+	if obj.m == nil {
+		return nil
+	}
+	sm := make(map[string]string, len(keys))
+	for _, k := range keys {
+		v := reveal(obj.m[k])
+		s, ok := v.(string)
+		if !ok {
+			jc.appendError(wrongType(key+"."+k, -1, "a string", v))
+			sm = nil
+			continue
+		}
+		if sm != nil {
+			sm[k] = s
+		}
+	}
+	return sm
+}
+
+func (jc Obj) requiredInt64Map(key string, required bool) map[string]int64 {
+	keys, obj := jc.mapEntries(key, required, "map of ints")
+	if obj.m == nil {
+		return nil
+	}
+	im := make(map[string]int64, len(keys))
+	for _, k := range keys {
+		v := reveal(obj.m[k])
+		n, err := toInt64(v, 64)
+		if err != nil {
+			jc.numberError(key+"."+k, v, "int64", err)
+			im = nil
+			continue
+		}
+		if im != nil {
+			im[k] = n
+		}
+	}
+	return im
+}
+
+func (jc Obj) requiredObjectMap(key string, required bool) map[string]Obj {
+	keys, obj := jc.mapEntries(key, required, "map of objects")
+	if obj.m == nil {
+		return nil
+	}
+	om := make(map[string]Obj, len(keys))
+	for _, k := range keys {
+		v := reveal(obj.m[k])
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			jc.appendError(wrongType(key+"."+k, -1, "an object", v))
+			om = nil
+			continue
+		}
+		if om != nil {
+			om[k] = obj.child(k, m)
+		}
+	}
+	return om
+}
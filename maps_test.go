@@ -0,0 +1,61 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMaps(t *testing.T) {
+	obj, err := ReadFile("testdata/maps.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := obj.RequiredStringMap("labels"), map[string]string{"env": "prod", "team": "infra"}; !reflect.DeepEqual(g, w) {
+		t.Errorf("labels = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredInt64Map("limits"), map[string]int64{"cpu": 4, "memory": 8192}; !reflect.DeepEqual(g, w) {
+		t.Errorf("limits = %v; want %v", g, w)
+	}
+	tenants := obj.RequiredObjectMap("tenants")
+	if len(tenants) != 2 {
+		t.Fatalf("tenants = %v", tenants)
+	}
+	if g := tenants["globex"].RequiredInt("quota"); g != 20 {
+		t.Errorf("globex quota = %d; want 20", g)
+	}
+	tenants["acme"].RequiredInt("quota")
+	if m := obj.OptionalStringMap("missing"); m != nil {
+		t.Errorf("missing = %v; want nil", m)
+	}
+	obj.OptionalInt64Map("missing")
+	obj.OptionalObjectMap("missing")
+
+	if g := obj.RequiredStringMap("bad_labels"); g != nil {
+		t.Errorf("bad_labels = %v; want nil", g)
+	}
+	obj.RequiredInt64Map("bad_limits")
+	obj.RequiredObjectMap("bad_tenants")
+	obj.RequiredStringMap("nowhere")
+
+	var merr MultiError
+	if err := obj.ValidateAll(); !errors.As(err, &merr) {
+		t.Fatalf("ValidateAll = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Expected config key "bad_labels.replicas" to be a string, not json.Number`,
+		`Expected config key "bad_labels.zone" to be a string, not bool`,
+		`Config key "bad_limits.cpu" value 1.5 cannot be converted to int64: not a whole number`,
+		`Expected config key "bad_tenants.initech" to be an object, not string`,
+		`Missing required config key "nowhere" (map of strings)`,
+		`Unknown key "tenants.globex.qouta"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
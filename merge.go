@@ -0,0 +1,227 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// A ListStrategy specifies how ReadFiles combines a list from one config
+// file with the list of the same key inherited from the files before it.
+type ListStrategy int
+
+const (
+	// ListReplace replaces the inherited list.
+	ListReplace ListStrategy = iota
+	// ListAppend appends to the inherited list.
+	ListAppend
+	// ListMergeByKey merges each object of the list into the inherited
+	// object with the same value for the parser's MergeKey, and appends
+	// the others. An object with a true "_delete" key removes the
+	// inherited object instead.
+	ListMergeByKey
+)
+
+// deleteKey is the expression that removes an inherited key when merging
+// config files: "key": ["_delete"].
+const deleteKey = "_delete"
+
+// ReadFiles reads and evaluates the config files at paths, like ReadFile,
+// and returns their deep merge: objects are merged key by key, each file
+// overriding the ones before it, and lists are combined according to
+// c.ListStrategies and c.ListStrategy. A key set to ["_delete"] removes
+// the key inherited from the previous files.
+func (c *ConfigParser) ReadFiles(paths ...string) (m map[string]interface{}, err error) {
+	merged := make(map[string]interface{})
+	touched := make(map[string]bool)
+	defer func() { c.touchedFiles = touched }()
+	c.positions = make(map[string]Origin)
+	defer func() { c.filePositions = nil }()
+	for _, path := range paths {
+		c.touchedFiles = make(map[string]bool)
+		c.filePositions = make(map[string]Origin)
+		layer, err := c.recursiveReadJSON(path)
+		for f := range c.touchedFiles {
+			touched[f] = true
+		}
+		if err != nil {
+			c.positions = nil
+			return nil, err
+		}
+		c.filePositions = settlePositions(layer, c.filePositions)
+		c.mergeObjects(merged, layer, nil, "", "")
+	}
+	if err = c.applyEnvOverrides(merged); err != nil {
+		c.positions = nil
+		return nil, err
+	}
+	c.rootJSON = merged
+	c.positions = settlePositions(merged, c.positions)
+	return c.rootJSON, nil
+}
+
+// ReadFiles reads, evaluates and merges the config files at paths in
+// order using the default merge options of ConfigParser.ReadFiles.
+func ReadFiles(paths ...string) (Obj, error) {
+	var c ConfigParser
+	m, err := c.ReadFiles(paths...)
+	if err != nil {
+		return Obj{}, err
+	}
+	return NewObj(m), nil
+}
+
+// listStrategy returns the strategy for the list at path.
+func (c *ConfigParser) listStrategy(path []string) ListStrategy {
+	if s, ok := c.ListStrategies[strings.Join(path, ".")]; ok {
+		return s
+	}
+	return c.ListStrategy
+}
+
+func (c *ConfigParser) mergeKey() string {
+	if c.MergeKey == "" {
+		return "name"
+	}
+	return c.MergeKey
+}
+
+// mergeObjects merges src into dst, src taking precedence. dstPtr and
+// srcPtr are the JSON Pointers of dst in the merged config and of src in
+// the file being merged, for tracking the positions of the values.
+func (c *ConfigParser) mergeObjects(dst, src map[string]interface{}, path []string, dstPtr, srcPtr string) {
+	for k, sv := range src {
+		if isDeleteMarker(sv) {
+			delete(dst, k)
+			continue
+		}
+		thisPath := appendPath(path, k)
+		esc := "/" + pointerEscaper.Replace(k)
+		switch s := sv.(type) {
+		case map[string]interface{}:
+			if d, ok := dst[k].(map[string]interface{}); ok {
+				c.mergeObjects(d, s, thisPath, dstPtr+esc, srcPtr+esc)
+				continue
+			}
+		case []interface{}:
+			if d, ok := dst[k].([]interface{}); ok {
+				dst[k] = c.mergeLists(d, s, thisPath, dstPtr+esc, srcPtr+esc)
+				continue
+			}
+		}
+		dst[k] = stripDeleteMarkers(sv)
+		c.movePositions(dstPtr+esc, srcPtr+esc)
+	}
+}
+
+func (c *ConfigParser) mergeLists(dst, src []interface{}, path []string, dstPtr, srcPtr string) []interface{} {
+	switch c.listStrategy(path) {
+	case ListAppend:
+		out := make([]interface{}, 0, len(dst)+len(src))
+		out = append(out, dst...)
+		for i, v := range src {
+			c.movePositions(dstPtr+"/"+strconv.Itoa(len(out)), srcPtr+"/"+strconv.Itoa(i))
+			out = append(out, stripDeleteMarkers(v))
+		}
+		return out
+	case ListMergeByKey:
+		return c.mergeByKey(dst, src, path, dstPtr, srcPtr)
+	}
+	c.movePositions(dstPtr, srcPtr)
+	return stripDeleteMarkers(src).([]interface{})
+}
+
+// mergeByKey merges the objects of src into the objects of dst having the
+// same merge key, appending the elements of src that match none.
+func (c *ConfigParser) mergeByKey(dst, src []interface{}, path []string, dstPtr, srcPtr string) []interface{} {
+	key := c.mergeKey()
+	out := append([]interface{}(nil), dst...)
+	index := make(map[string]int)
+	for i, v := range out {
+		if id, ok := mergeID(v, key); ok {
+			index[id] = i
+		}
+	}
+	removed := make(map[int]bool)
+	for j, sv := range src {
+		elemPtr := srcPtr + "/" + strconv.Itoa(j)
+		id, ok := mergeID(sv, key)
+		if !ok {
+			c.movePositions(dstPtr+"/"+strconv.Itoa(len(out)), elemPtr)
+			out = append(out, stripDeleteMarkers(sv))
+			continue
+		}
+		s := sv.(map[string]interface{})
+		i, found := index[id]
+		if del, ok := s[deleteKey].(bool); ok {
+			if del {
+				if found {
+					removed[i] = true
+					delete(index, id)
+				}
+				continue
+			}
+			delete(s, deleteKey)
+		}
+		if !found {
+			index[id] = len(out)
+			c.movePositions(dstPtr+"/"+strconv.Itoa(len(out)), elemPtr)
+			out = append(out, stripDeleteMarkers(sv))
+			continue
+		}
+		c.mergeObjects(out[i].(map[string]interface{}), s, path, dstPtr+"/"+strconv.Itoa(i), elemPtr)
+	}
+	if len(removed) == 0 {
+		return out
+	}
+	kept := out[:0]
+	newIndex := make(map[int]int)
+	for i, v := range out {
+		if !removed[i] {
+			newIndex[i] = len(kept)
+			kept = append(kept, v)
+		}
+	}
+	c.renumberPositions(dstPtr, newIndex)
+	return kept
+}
+
+// mergeID returns the merge key value identifying the list element v.
+func mergeID(v interface{}, key string) (string, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	switch id := m[key].(type) {
+	case string:
+		return "s" + id, true
+	case json.Number:
+		return "n" + id.String(), true
+	}
+	return "", false
+}
+
+func isDeleteMarker(v interface{}) bool {
+	l, ok := v.([]interface{})
+	return ok && len(l) == 1 && l[0] == deleteKey
+}
+
+// stripDeleteMarkers removes the keys of the objects in v that are set to
+// the delete expression, since there is nothing left for them to delete.
+func stripDeleteMarkers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, sv := range t {
+			if isDeleteMarker(sv) {
+				delete(t, k)
+				continue
+			}
+			t[k] = stripDeleteMarkers(sv)
+		}
+	case []interface{}:
+		for i, sv := range t {
+			t[i] = stripDeleteMarkers(sv)
+		}
+	}
+	return v
+}
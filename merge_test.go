@@ -0,0 +1,73 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestReadFiles(t *testing.T) {
+	os.Setenv("TEST_LAYER_HOST", "web1")
+	c := ConfigParser{
+		ListStrategies: map[string]ListStrategy{"upstreams": ListMergeByKey},
+	}
+	m, err := c.ReadFiles("testdata/layer_base.json", "testdata/layer_env.json", "testdata/layer_host.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := func(name, url string, weight int) map[string]interface{} {
+		return map[string]interface{}{"name": name, "url": url, "weight": json.Number(strconv.Itoa(weight))}
+	}
+	want := map[string]interface{}{
+		"host": "web1",
+		"port": json.Number("9090"),
+		"tags": []interface{}{"prod"},
+		"db": map[string]interface{}{
+			"user": "app",
+			"pool": map[string]interface{}{"min": json.Number("1"), "max": json.Number("50")},
+		},
+		"upstreams": []interface{}{
+			upstream("a", "http://a", 1),
+			upstream("b", "http://b", 5),
+			upstream("d", "http://d", 1),
+		},
+		"common": map[string]interface{}{"key": "value"},
+		"extra":  map[string]interface{}{"kept": json.Number("1")},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("got  %v\nwant %v", m, want)
+	}
+}
+
+func TestReadFilesAppend(t *testing.T) {
+	c := ConfigParser{ListStrategy: ListAppend}
+	m, err := c.ReadFiles("testdata/layer_base.json", "testdata/layer_env.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := NewObj(m)
+	if g, e := obj.RequiredList("tags"), []string{"base", "prod"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("tags = %q; want %q", g, e)
+	}
+	if g, e := len(m["upstreams"].([]interface{})), 6; g != e {
+		t.Errorf("len(upstreams) = %d; want %d", g, e)
+	}
+}
+
+func TestReadFilesReplace(t *testing.T) {
+	obj, err := ReadFiles("testdata/layer_base.json", "testdata/layer_env.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := obj.RequiredList("tags"), []string{"prod"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("tags = %q; want %q", g, e)
+	}
+	if g, e := len(obj.Map()["upstreams"].([]interface{})), 3; g != e {
+		t.Errorf("len(upstreams) = %d; want %d", g, e)
+	}
+	if _, err := ReadFiles("testdata/layer_base.json", "testdata/loop1.json"); err == nil {
+		t.Error("expected an include cycle error from the second file")
+	}
+}
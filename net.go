@@ -0,0 +1,162 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+)
+
+// A HostPort is a host name or IP address and a port, as written in a
+// config value like "db.example.com:5432", "[::1]:80" or ":8080".
+type HostPort struct {
+	Host string // empty for all local addresses
+	Port uint16
+}
+
+// String returns hp in the host:port form accepted by net.Dial.
+func (hp HostPort) String() string {
+	return net.JoinHostPort(hp.Host, strconv.Itoa(int(hp.Port)))
+}
+
+// RequiredURL returns the absolute URL at key.
+func (jc Obj) RequiredURL(key string) *url.URL {
+	return parseText(jc, key, "URL", nil, parseURL)
+}
+
+func (jc Obj) OptionalURL(key string, def *url.URL) *url.URL {
+	return parseText(jc, key, "URL", &def, parseURL)
+}
+
+func (jc Obj) RequiredHostPort(key string) HostPort {
+	return parseText(jc, key, "host:port", nil, parseHostPort)
+}
+
+func (jc Obj) OptionalHostPort(key string, def HostPort) HostPort {
+	return parseText(jc, key, "host:port", &def, parseHostPort)
+}
+
+func (jc Obj) RequiredIP(key string) netip.Addr {
+	return parseText(jc, key, "IP address", nil, netip.ParseAddr)
+}
+
+func (jc Obj) OptionalIP(key string, def netip.Addr) netip.Addr {
+	return parseText(jc, key, "IP address", &def, netip.ParseAddr)
+}
+
+func (jc Obj) RequiredIPList(key string) []netip.Addr {
+	return parseTextList(jc, key, "IP address", "list of IP addresses", true, netip.ParseAddr)
+}
+
+func (jc Obj) OptionalIPList(key string) []netip.Addr {
+	return parseTextList(jc, key, "IP address", "list of IP addresses", false, netip.ParseAddr)
+}
+
+// RequiredPrefix returns the IP network at key, written in CIDR notation
+// like "10.0.0.0/8".
+func (jc Obj) RequiredPrefix(key string) netip.Prefix {
+	return parseText(jc, key, "CIDR prefix", nil, netip.ParsePrefix)
+}
+
+func (jc Obj) OptionalPrefix(key string, def netip.Prefix) netip.Prefix {
+	return parseText(jc, key, "CIDR prefix", &def, netip.ParsePrefix)
+}
+
+func (jc Obj) RequiredPrefixList(key string) []netip.Prefix {
+	return parseTextList(jc, key, "CIDR prefix", "list of CIDR prefixes", true, netip.ParsePrefix)
+}
+
+func (jc Obj) OptionalPrefixList(key string) []netip.Prefix {
+	return parseTextList(jc, key, "CIDR prefix", "list of CIDR prefixes", false, netip.ParsePrefix)
+}
+
+func (jc Obj) RequiredRegexp(key string) *regexp.Regexp {
+	return parseText(jc, key, "regexp", nil, regexp.Compile)
+}
+
+func (jc Obj) OptionalRegexp(key string, def *regexp.Regexp) *regexp.Regexp {
+	return parseText(jc, key, "regexp", &def, regexp.Compile)
+}
+
+// parseText returns the string at key converted by parse, or *def if the
+// key is missing and def is not nil. typ describes the expected value in
+// errors.
+func parseText[T any](jc Obj, key, typ string, def *T, parse func(string) (T, error)) T {
+	var zero T
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if def != nil {
+			return *def
+		}
+		jc.appendError(missingKey(key, typ))
+		return zero
+	}
+	s, ok := ei.(string)
+	if !ok {
+		jc.appendError(wrongType(key, -1, "a string", ei))
+		return zero
+	}
+	v, err := parse(s)
+	if err != nil {
+		jc.appendError(jc.badValue(key, -1, s, typ, err))
+		return zero
+	}
+	return v
+}
+
+// parseTextList is like parseText for a list of strings, described by
+// listTyp, and returns nil if the list is missing or has an error.
+func parseTextList[T any](jc Obj, key, typ, listTyp string, required bool, parse func(string) (T, error)) []T {
+	eil, ok := jc.list(key, required, listTyp)
+	if !ok {
+		return nil
+	}
+	l := make([]T, len(eil))
+	for i, ei := range eil {
+		s, ok := ei.(string)
+		if !ok {
+			jc.appendError(wrongType(key, i, "a string", ei))
+			return nil
+		}
+		v, err := parse(s)
+		if err != nil {
+			jc.appendError(jc.badValue(key, i, s, typ, err))
+			return nil
+		}
+		l[i] = v
+	}
+	return l
+}
+
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			return nil, uerr.Err
+		}
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, errors.New("not an absolute URL")
+	}
+	return u, nil
+}
+
+func parseHostPort(s string) (HostPort, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		if aerr, ok := err.(*net.AddrError); ok {
+			return HostPort{}, errors.New(aerr.Err)
+		}
+		return HostPort{}, err
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return HostPort{}, errors.New("invalid port " + strconv.Quote(port))
+	}
+	return HostPort{Host: host, Port: uint16(n)}, nil
+}
@@ -0,0 +1,94 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestNetAccessors(t *testing.T) {
+	obj, err := ReadFile("testdata/net.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := obj.RequiredURL("endpoint"); u == nil || u.Host != "api.example.com" || u.Path != "/v1" {
+		t.Errorf("endpoint = %v", u)
+	}
+	if u := obj.OptionalURL("missing", nil); u != nil {
+		t.Errorf("missing = %v; want nil", u)
+	}
+	hostPorts := []struct {
+		key  string
+		want HostPort
+		str  string
+	}{
+		{"listen", HostPort{"", 8080}, ":8080"},
+		{"db", HostPort{"db.example.com", 5432}, "db.example.com:5432"},
+		{"v6", HostPort{"::1", 443}, "[::1]:443"},
+	}
+	for _, tt := range hostPorts {
+		hp := obj.RequiredHostPort(tt.key)
+		if hp != tt.want || hp.String() != tt.str {
+			t.Errorf("%s = %+v (%s); want %+v (%s)", tt.key, hp, hp, tt.want, tt.str)
+		}
+	}
+	if g, w := obj.RequiredIP("bind"), netip.MustParseAddr("192.0.2.1"); g != w {
+		t.Errorf("bind = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredIPList("dns"), []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("2606:4700:4700::1111")}; !reflect.DeepEqual(g, w) {
+		t.Errorf("dns = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredPrefix("trusted"), netip.MustParsePrefix("10.0.0.0/8"); g != w {
+		t.Errorf("trusted = %v; want %v", g, w)
+	}
+	if g := obj.RequiredPrefixList("allow"); len(g) != 2 || !g[1].Contains(netip.MustParseAddr("fd12::1")) {
+		t.Errorf("allow = %v", g)
+	}
+	if re := obj.RequiredRegexp("name_pattern"); re == nil || !re.MatchString("web-1") || re.MatchString("1web") {
+		t.Errorf("name_pattern = %v", re)
+	}
+	if g := obj.OptionalHostPort("missing", HostPort{"localhost", 80}); g.String() != "localhost:80" {
+		t.Errorf("missing = %v", g)
+	}
+	if g := obj.OptionalIPList("missing"); g != nil {
+		t.Errorf("missing = %v; want nil", g)
+	}
+
+	for _, k := range []string{"bad_endpoint", "bad_url"} {
+		obj.RequiredURL(k)
+	}
+	obj.RequiredHostPort("no_port")
+	obj.RequiredHostPort("bad_port")
+	obj.RequiredIP("bad_ip")
+	obj.RequiredIPList("bad_dns")
+	obj.RequiredPrefix("bad_prefix")
+	obj.RequiredRegexp("bad_pattern")
+	obj.RequiredRegexp("nowhere")
+	obj.RequiredIP("dns")
+
+	var merr MultiError
+	if err := obj.Validate(); !errors.As(err, &merr) {
+		t.Fatalf("Validate = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Config key "bad_endpoint" value "/relative/path" cannot be converted to URL: not an absolute URL`,
+		`Config key "bad_url" value "http://[::1" cannot be converted to URL: missing ']' in host`,
+		`Config key "no_port" value "db.example.com" cannot be converted to host:port: missing port in address`,
+		`Config key "bad_port" value "db:http" cannot be converted to host:port: invalid port "http"`,
+		`Config key "bad_ip" value "300.1.1.1" cannot be converted to IP address: ParseAddr("300.1.1.1"): IPv4 field has value >255`,
+		`Config key "bad_dns" index 1 value "localhost" cannot be converted to IP address: ParseAddr("localhost"): unable to parse IP`,
+		`Config key "bad_prefix" value "10.0.0.0/33" cannot be converted to CIDR prefix: netip.ParsePrefix("10.0.0.0/33"): prefix length out of range`,
+		"Config key \"bad_pattern\" value \"(unclosed\" cannot be converted to regexp: error parsing regexp: missing closing ): `(unclosed`",
+		`Missing required config key "nowhere" (regexp)`,
+		`Expected config key "dns" to be a string, not []interface {}`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Reasons a config value cannot be converted to an integer type.
+var (
+	errNotNumber = errors.New("not a number")
+	errFraction  = errors.New("not a whole number")
+	errRange     = errors.New("out of range")
+	errNegative  = errors.New("negative value for an unsigned type")
+)
+
+// maxExactInt bounds the magnitude of numbers that are converted exactly
+// through big.Rat; anything larger overflows every supported type anyway.
+const maxExactInt = 1 << 64
+
+// parseInteger returns the exact integer value of the JSON number ei, which
+// is normally a json.Number but may be any Go number in a hand-built Obj.
+func parseInteger(ei interface{}) (*big.Int, error) {
+	switch v := ei.(type) {
+	case json.Number:
+		s := string(v)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return big.NewInt(n), nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil && !isRangeError(err) {
+			return nil, errNotNumber
+		}
+		if math.Abs(f) > maxExactInt {
+			if math.Trunc(f) != f {
+				return nil, errFraction
+			}
+			return nil, errRange
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, errNotNumber
+		}
+		if !r.IsInt() {
+			return nil, errFraction
+		}
+		return new(big.Int).Set(r.Num()), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, errRange
+		}
+		if math.Trunc(v) != v {
+			return nil, errFraction
+		}
+		if math.Abs(v) > maxExactInt {
+			return nil, errRange
+		}
+		n, _ := big.NewFloat(v).Int(nil)
+		return n, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	}
+	return nil, errNotNumber
+}
+
+func isRangeError(err error) bool {
+	ne, ok := err.(*strconv.NumError)
+	return ok && ne.Err == strconv.ErrRange
+}
+
+// toInt64 converts the JSON number ei to a signed integer of the given
+// bit size without loss of precision.
+func toInt64(ei interface{}, bitSize int) (int64, error) {
+	n, err := parseInteger(ei)
+	if err != nil {
+		return 0, err
+	}
+	min := new(big.Int).Lsh(big.NewInt(-1), uint(bitSize-1))
+	max := new(big.Int).Not(min)
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 {
+		return 0, errRange
+	}
+	return n.Int64(), nil
+}
+
+// toUint64 converts the JSON number ei to an unsigned integer of the given
+// bit size without loss of precision.
+func toUint64(ei interface{}, bitSize int) (uint64, error) {
+	n, err := parseInteger(ei)
+	if err != nil {
+		return 0, err
+	}
+	if n.Sign() < 0 {
+		return 0, errNegative
+	}
+	if n.BitLen() > bitSize {
+		return 0, errRange
+	}
+	return n.Uint64(), nil
+}
+
+// toFloat64 converts the JSON number ei to the nearest float64.
+func toFloat64(ei interface{}) (float64, error) {
+	switch v := ei.(type) {
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if isRangeError(err) {
+			return 0, errRange
+		}
+		if err != nil {
+			return 0, errNotNumber
+		}
+		return f, nil
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, errNotNumber
+}
+
+// numberError records the failure to convert the value ei of key to typ.
+func (jc Obj) numberError(key string, ei interface{}, typ string, err error) {
+	jc.numberIndexError(key, -1, ei, typ, err)
+}
+
+// numberIndexError records the failure to convert the value ei at index i
+// of the list key, or of key itself if i is -1, to typ.
+func (jc Obj) numberIndexError(key string, i int, ei interface{}, typ string, err error) {
+	if err == errNotNumber {
+		jc.appendError(wrongType(key, i, "a number", ei))
+		return
+	}
+	jc.appendError(jc.badValue(key, i, ei, typ, err))
+}
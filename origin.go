@@ -0,0 +1,103 @@
+package jsoncfgo
+
+import (
+	"strings"
+)
+
+// An Origin tells where a value of a config came from: the position where
+// it was written, the expression that produced it, if any, and the chain
+// of _fileobj expressions through which its file was included.
+type Origin struct {
+	Position
+
+	// Expander is the name of the expression that produced the value,
+	// such as "_env" or "_fileobj", and Source what it read: the
+	// environment variables of _env, separated by commas, or the file
+	// included by _fileobj. The values nested in a value produced by
+	// an expression share its Origin, except for those read from an
+	// included file.
+	Expander string
+	Source   string
+
+	// EnvVar is the environment variable that replaced the value, or
+	// the value holding it, because of ConfigParser.EnvPrefix.
+	EnvVar string
+
+	// Included is the origin of the _fileobj expression that included
+	// File, or nil if File was read directly.
+	Included *Origin
+}
+
+// String returns the position of o followed by its expression and
+// environment override, if any, as in
+//
+//	db.json:3:15 (_env DB_PASSWORD, overridden by APP_DB_PASSWORD)
+func (o Origin) String() string {
+	var notes []string
+	if o.Expander != "" {
+		notes = append(notes, strings.TrimSpace(o.Expander+" "+o.Source))
+	}
+	if o.EnvVar != "" {
+		notes = append(notes, "overridden by "+o.EnvVar)
+	}
+	if len(notes) == 0 {
+		return o.Position.String()
+	}
+	return o.Position.String() + " (" + strings.Join(notes, ", ") + ")"
+}
+
+// Origin returns the origin of the value at path in the config read by
+// the last call to ReadFile or ReadFiles, as Position does, along with the
+// expression that produced it and the includes leading to its file.
+func (c *ConfigParser) Origin(path string) (Origin, bool) {
+	segs, err := splitPath(path)
+	if err != nil {
+		return Origin{}, false
+	}
+	return c.origin(segs)
+}
+
+func (c *ConfigParser) origin(segs []string) (Origin, bool) {
+	o, ok := c.positions[jsonPointer(segs)]
+	if !ok {
+		return Origin{}, false
+	}
+	for i := len(segs); i >= 0; i-- {
+		if name, ok := c.envOverrides[jsonPointer(segs[:i])]; ok {
+			o.EnvVar = name
+			break
+		}
+	}
+	for i := len(segs) - 1; i >= 0; i-- {
+		inc := c.positions[jsonPointer(segs[:i])]
+		if inc.Expander == "_fileobj" && inc.Source == o.File {
+			if inc, ok := c.origin(segs[:i]); ok {
+				o.Included = &inc
+			}
+			break
+		}
+	}
+	return o, true
+}
+
+// noteExpander records that the value being evaluated was produced by the
+// expander name, which read source. The positions recorded for the
+// arguments of the expression are dropped, so that the values nested in
+// its result share its origin.
+func (c *ConfigParser) noteExpander(name, source string) {
+	if c.filePositions == nil {
+		return
+	}
+	ptr := jsonPointer(append(append([]string(nil), c.rootPath...), c.keyPath...))
+	o, ok := c.filePositions[ptr]
+	if !ok {
+		return
+	}
+	for p, arg := range c.filePositions {
+		if p != ptr && underPointer(p, ptr) && arg.File == o.File {
+			delete(c.filePositions, p)
+		}
+	}
+	o.Expander, o.Source = name, source
+	c.filePositions[ptr] = o
+}
@@ -0,0 +1,72 @@
+package jsoncfgo
+
+import (
+	"strings"
+	"testing"
+)
+
+// originChain returns o and the origins of its includes, one per line.
+func originChain(o Origin) string {
+	links := []string{o.String()}
+	for inc := o.Included; inc != nil; inc = inc.Included {
+		links = append(links, inc.String())
+	}
+	return strings.Join(links, "\n")
+}
+
+func TestOrigin(t *testing.T) {
+	t.Setenv("JSONCFGO_TEST_PASSWORD", "hunter2")
+	t.Setenv("APP_DB_USER", "admin")
+	c := ConfigParser{EnvPrefix: "APP"}
+	if _, err := c.ReadFile("testdata/origin.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"name", "testdata/origin.json:2:11"},
+		{"/db", "testdata/origin.json:3:9 (_fileobj testdata/origin_db.json)"},
+		{"port", "testdata/origin.json:4:11 (_env JSONCFGO_TEST_PORT)"},
+		{"db.user", "testdata/origin_db.json:2:11 (overridden by APP_DB_USER)\n" +
+			"testdata/origin.json:3:9 (_fileobj testdata/origin_db.json)"},
+		{"db.password", "testdata/origin_db.json:3:15 (_env JSONCFGO_TEST_PASSWORD)\n" +
+			"testdata/origin.json:3:9 (_fileobj testdata/origin_db.json)"},
+		{"db.replica.ports.1", "testdata/origin_replica.json:1:43\n" +
+			"testdata/origin_db.json:4:14 (_fileobj testdata/origin_replica.json)\n" +
+			"testdata/origin.json:3:9 (_fileobj testdata/origin_db.json)"},
+	} {
+		o, ok := c.Origin(tt.path)
+		if !ok {
+			t.Errorf("Origin(%q) not found", tt.path)
+			continue
+		}
+		if g := originChain(o); g != tt.want {
+			t.Errorf("Origin(%q) =\n%s\nwant\n%s", tt.path, g, tt.want)
+		}
+	}
+	if o, ok := c.Origin("db.host"); ok {
+		t.Errorf("Origin(db.host) = %v; want none", o)
+	}
+}
+
+func TestOriginMerged(t *testing.T) {
+	t.Setenv("JSONCFGO_TEST_PASSWORD", "hunter2")
+	var c ConfigParser
+	if _, err := c.ReadFiles("testdata/origin.json", "testdata/origin_replica.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		want string
+	}{
+		{"host", "testdata/origin_replica.json:1:10"},
+		{"db.replica.host", "testdata/origin_replica.json:1:10\n" +
+			"testdata/origin_db.json:4:14 (_fileobj testdata/origin_replica.json)\n" +
+			"testdata/origin.json:3:9 (_fileobj testdata/origin_db.json)"},
+	} {
+		if o, _ := c.Origin(tt.path); originChain(o) != tt.want {
+			t.Errorf("Origin(%q) =\n%s\nwant\n%s", tt.path, originChain(o), tt.want)
+		}
+	}
+}
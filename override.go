@@ -0,0 +1,189 @@
+package jsoncfgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvOverrides returns the values of the last config read that were
+// replaced by environment variables because of c.EnvPrefix, as a map from
+// the dotted key path of each value to the name of its variable.
+func (c *ConfigParser) EnvOverrides() map[string]string {
+	overrides := make(map[string]string, len(c.envOverrides))
+	for ptr, v := range c.envOverrides {
+		segs, _ := splitPath(ptr)
+		overrides[strings.Join(segs, ".")] = v
+	}
+	return overrides
+}
+
+// EnvVarName returns the name of the environment variable overriding the
+// value at the given key path when EnvPrefix is prefix: the prefix and
+// the keys, upper cased and joined by underscores, with any character
+// other than a letter or digit replaced by an underscore.
+func EnvVarName(prefix string, path ...string) string {
+	parts := append([]string{prefix}, path...)
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// applyEnvOverrides replaces the values of m for which an environment
+// variable is set, if c.EnvPrefix is set and c.Raw is not.
+func (c *ConfigParser) applyEnvOverrides(m map[string]interface{}) error {
+	c.envOverrides = nil
+	if c.EnvPrefix == "" || c.Raw {
+		return nil
+	}
+	c.envOverrides = make(map[string]string)
+	return c.overrideObject(m, nil)
+}
+
+func (c *ConfigParser) overrideObject(m map[string]interface{}, path []string) error {
+	for k, v := range m {
+		thisPath := appendPath(path, k)
+		if err := c.overrideValue(v, thisPath, func(nv interface{}) { m[k] = nv }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overrideValue calls set with the value of the environment variable for
+// the value v at path, converted to the type of v, or else looks for
+// overrides of the values v contains. The value overriding a Secret, or a
+// list or object holding one, is marked secret like v.
+func (c *ConfigParser) overrideValue(v interface{}, path []string, set func(interface{})) error {
+	name := EnvVarName(c.EnvPrefix, path...)
+	if s, ok := os.LookupEnv(name); ok {
+		for ptr, other := range c.envOverrides {
+			if other == name {
+				segs, _ := splitPath(ptr)
+				return fmt.Errorf("environment variable %s overrides both %s and %s",
+					name, strings.Join(segs, "."), strings.Join(path, "."))
+			}
+		}
+		secret := holdsSecret(v)
+		nv, err := coerceEnv(strings.Join(path, "."), -1, s, reveal(v))
+		if err != nil {
+			if e, ok := err.(*ValueError); ok && secret {
+				s := Secret{e.Value}
+				e.Value, e.Err = s, redactError(e.Err, s)
+			}
+			return fmt.Errorf("bad value for environment variable %s: %w", name, err)
+		}
+		if secret {
+			nv = markSecret(nv)
+		}
+		set(nv)
+		c.envOverrides[jsonPointer(path)] = name
+		return nil
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return c.overrideObject(t, path)
+	case []interface{}:
+		for i, ev := range t {
+			if _, ok := ev.(map[string]interface{}); !ok {
+				continue
+			}
+			i := i
+			if err := c.overrideValue(ev, appendPath(path, strconv.Itoa(i)), func(nv interface{}) { t[i] = nv }); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// coerceEnv converts the environment variable value s to the type of the
+// config value old it replaces, at key, or at index in the list at key if
+// index is not -1. Lists may be given either as JSON arrays or as comma
+// separated values, an empty value being an empty list, and objects as
+// JSON objects.
+func coerceEnv(key string, index int, s string, old interface{}) (interface{}, error) {
+	switch t := old.(type) {
+	case string:
+		return s, nil
+	case bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, &ValueError{Key: key, Index: index, Value: s, Type: "boolean", Err: err}
+		}
+		return b, nil
+	case json.Number, float64:
+		return decodeEnvValue(key, index, s, "number", "a number", func(v interface{}) bool {
+			_, ok := v.(json.Number)
+			return ok
+		})
+	case []interface{}:
+		if strings.HasPrefix(strings.TrimSpace(s), "[") {
+			return decodeEnvValue(key, index, s, "list", "a list", func(v interface{}) bool {
+				_, ok := v.([]interface{})
+				return ok
+			})
+		}
+		if strings.TrimSpace(s) == "" {
+			return []interface{}{}, nil
+		}
+		var elem interface{} = ""
+		if len(t) > 0 {
+			elem = t[0]
+		}
+		parts := strings.Split(s, ",")
+		l := make([]interface{}, len(parts))
+		for i, p := range parts {
+			v, err := coerceEnv(key, i, strings.TrimSpace(p), elem)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		return l, nil
+	case map[string]interface{}:
+		return decodeEnvValue(key, index, s, "object", "an object", func(v interface{}) bool {
+			_, ok := v.(map[string]interface{})
+			return ok
+		})
+	}
+	if v, err := decodeEnvJSON(s); err == nil {
+		return v, nil
+	}
+	return s, nil
+}
+
+// decodeEnvValue decodes s as a JSON value of type typ, described by want,
+// for which ok returns true.
+func decodeEnvValue(key string, index int, s, typ, want string, ok func(interface{}) bool) (interface{}, error) {
+	v, err := decodeEnvJSON(s)
+	if err != nil {
+		return nil, &ValueError{Key: key, Index: index, Value: s, Type: typ, Err: err}
+	}
+	if !ok(v) {
+		return nil, wrongType(key, index, want, v)
+	}
+	return v, nil
+}
+
+// decodeEnvJSON decodes s as a single JSON value, keeping numbers exact.
+func decodeEnvJSON(s string) (interface{}, error) {
+	dj := json.NewDecoder(bytes.NewReader([]byte(s)))
+	dj.UseNumber()
+	var v interface{}
+	if err := dj.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dj.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
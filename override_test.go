@@ -0,0 +1,125 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	env := map[string]string{
+		"TESTAPP_DATABASE_HOST":     "db.internal",
+		"TESTAPP_DATABASE_PORT":     "6543",
+		"TESTAPP_DATABASE_SSL":      "true",
+		"TESTAPP_DATABASE_REPLICAS": "r3, r4",
+		"TESTAPP_DATABASE_WEIGHTS":  "[5, 6, 7]",
+		"TESTAPP_SERVERS_0_PORT":    "8080",
+		"TESTAPP_LOG_LEVEL":         "debug",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+	c := ConfigParser{EnvPrefix: "TESTAPP"}
+	m, err := c.ReadFile("testdata/override.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := NewObj(m)
+	db := obj.RequiredObject("database")
+	if g, e := db.RequiredString("host"), "db.internal"; g != e {
+		t.Errorf("host = %q; want %q", g, e)
+	}
+	if g, e := db.RequiredInt("port"), 6543; g != e {
+		t.Errorf("port = %d; want %d", g, e)
+	}
+	if !db.RequiredBool("ssl") {
+		t.Error("ssl = false; want true")
+	}
+	if g, e := db.RequiredList("replicas"), []string{"r3", "r4"}; !reflect.DeepEqual(g, e) {
+		t.Errorf("replicas = %q; want %q", g, e)
+	}
+	if g, e := db.IntList("weights"), []int64{5, 6, 7}; !reflect.DeepEqual(g, e) {
+		t.Errorf("weights = %v; want %v", g, e)
+	}
+	server := obj.RequiredObjectList("servers")[0]
+	if g, e := server.RequiredInt("port"), 8080; g != e {
+		t.Errorf("servers.0.port = %d; want %d", g, e)
+	}
+	if g, e := obj.RequiredString("log-level"), "debug"; g != e {
+		t.Errorf("log-level = %q; want %q", g, e)
+	}
+	want := map[string]string{
+		"database.host":     "TESTAPP_DATABASE_HOST",
+		"database.port":     "TESTAPP_DATABASE_PORT",
+		"database.ssl":      "TESTAPP_DATABASE_SSL",
+		"database.replicas": "TESTAPP_DATABASE_REPLICAS",
+		"database.weights":  "TESTAPP_DATABASE_WEIGHTS",
+		"servers.0.port":    "TESTAPP_SERVERS_0_PORT",
+		"log-level":         "TESTAPP_LOG_LEVEL",
+	}
+	if g := c.EnvOverrides(); !reflect.DeepEqual(g, want) {
+		t.Errorf("EnvOverrides = %v; want %v", g, want)
+	}
+}
+
+func TestEnvOverrideErrors(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+		kind              error
+	}{
+		{"TESTAPP_DATABASE_PORT", "lots", `bad value for environment variable TESTAPP_DATABASE_PORT: Config key "database.port" value "lots" cannot be converted to number`, ErrBadValue},
+		{"TESTAPP_DATABASE_PORT", `"1"`, `Expected config key "database.port" to be a number, not string`, ErrWrongType},
+		{"TESTAPP_DATABASE_SSL", "maybe", `bad value for environment variable TESTAPP_DATABASE_SSL: Config key "database.ssl" value "maybe" cannot be converted to boolean`, ErrBadValue},
+		{"TESTAPP_DATABASE_WEIGHTS", "1,x", `Config key "database.weights" index 1 value "x" cannot be converted to number`, ErrBadValue},
+		{"TESTAPP_DATABASE", "[1]", `Expected config key "database" to be an object, not []interface {}`, ErrWrongType},
+	}
+	for _, tt := range tests {
+		os.Setenv(tt.name, tt.value)
+		c := ConfigParser{EnvPrefix: "TESTAPP"}
+		_, err := c.ReadFile("testdata/override.json")
+		os.Unsetenv(tt.name)
+		if err == nil || !strings.Contains(err.Error(), tt.want) || !errors.Is(err, tt.kind) {
+			t.Errorf("%s=%q: got error %v; want %q (%v)", tt.name, tt.value, err, tt.want, tt.kind)
+		}
+	}
+}
+
+func TestEnvOverrideEmptyList(t *testing.T) {
+	t.Setenv("TESTAPP_DATABASE_REPLICAS", "")
+	c := ConfigParser{EnvPrefix: "TESTAPP"}
+	m, err := c.ReadFile("testdata/override.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := NewObj(m).RequiredObject("database").RequiredList("replicas"); g == nil || len(g) != 0 {
+		t.Errorf("replicas = %q; want an empty list", g)
+	}
+}
+
+func TestEnvOverrideCollision(t *testing.T) {
+	t.Setenv("TESTAPP_A_B", "2")
+	c := ConfigParser{EnvPrefix: "TESTAPP"}
+	_, err := c.ReadFile("testdata/override_collision.json")
+	if err == nil || !strings.Contains(err.Error(), "environment variable TESTAPP_A_B overrides both") {
+		t.Errorf("got error %v; want a collision of a_b and a.b", err)
+	}
+}
+
+func TestEnvOverridesDisabled(t *testing.T) {
+	os.Setenv("TESTAPP_UNTOUCHED", "changed")
+	defer os.Unsetenv("TESTAPP_UNTOUCHED")
+	var c ConfigParser
+	m, err := c.ReadFile("testdata/override.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := NewObj(m).RequiredString("untouched"), "value"; g != e {
+		t.Errorf("untouched = %q; want %q", g, e)
+	}
+	if len(c.EnvOverrides()) != 0 {
+		t.Errorf("EnvOverrides = %v; want none", c.EnvOverrides())
+	}
+}
@@ -0,0 +1,211 @@
+package jsoncfgo
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Path is a value nested in a config, as returned by At. Its accessors
+// are those of Obj without the key argument. Their errors are recorded on
+// the config At was called on, keyed by the full path.
+type Path struct {
+	view Obj
+	key  string
+}
+
+// At returns the value at path in jc. The path is either a JSON Pointer
+// (RFC 6901) such as "/servers/0/host", or a dotted path such as
+// "servers.0.host", whose keys cannot contain dots. List elements are
+// addressed by their index. The keys along the path are marked as known,
+// so
+//
+//	cfg.At("database.primary.port").RequiredInt()
+//
+// is like
+//
+//	cfg.RequiredObject("database").RequiredObject("primary").RequiredInt("port")
+//
+// except that a missing or bad value is reported by cfg.Validate, as a
+// "database.primary.port" error, rather than by the nested objects.
+func (jc Obj) At(path string) Path {
+	return Path{view: jc.at(path), key: path}
+}
+
+// at returns a view of the value at path in jc: an Obj holding the value,
+// if any, under the key path, whose errors are recorded on jc. If path is
+// malformed or crosses a value that is neither an object nor a list, that
+// error is recorded instead, and those of the view are dropped.
+func (jc Obj) at(path string) Obj {
+	view := NewObj(nil)
+	segs, err := splitPath(path)
+	if err != nil {
+		jc.appendError(err)
+		return view
+	}
+	var v interface{} = jc.m
+	vt := jc.t
+	found := true
+	for i, seg := range segs {
+		if i > 0 {
+			vt = vt.child(segs[i-1])
+		}
+		switch t := v.(type) {
+		case map[string]interface{}:
+			if vt != nil {
+				vt.noteKnownKey(seg)
+			}
+			v, found = t[seg]
+		case []interface{}:
+			n, err := strconv.Atoi(seg)
+			found = err == nil && n >= 0 && n < len(t) && strconv.Itoa(n) == seg
+			if found {
+				v = t[n]
+			}
+		default:
+			jc.appendError(wrongType(pathPrefix(path, segs[:i]), -1, "an object or list", v))
+			return view
+		}
+		if !found {
+			break
+		}
+	}
+	if jc.t != nil {
+		view.t.forward = jc.t
+	}
+	if found {
+		view.m[path] = v
+		if len(segs) > 0 {
+			view.t.viewed, view.t.viewedKey = vt, segs[len(segs)-1]
+		}
+	}
+	return view
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// splitPath returns the keys of a dotted path or JSON Pointer.
+func splitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return strings.Split(path, "."), nil
+	}
+	segs := strings.Split(path[1:], "/")
+	for i, seg := range segs {
+		if !strings.Contains(seg, "~") {
+			continue
+		}
+		var b strings.Builder
+		for j := 0; j < len(seg); j++ {
+			if seg[j] != '~' {
+				b.WriteByte(seg[j])
+				continue
+			}
+			if j+1 == len(seg) || seg[j+1] != '0' && seg[j+1] != '1' {
+				return nil, fmt.Errorf("invalid JSON Pointer %q: bad escape in %q", path, seg)
+			}
+			j++
+			if seg[j] == '0' {
+				b.WriteByte('~')
+			} else {
+				b.WriteByte('/')
+			}
+		}
+		segs[i] = b.String()
+	}
+	return segs, nil
+}
+
+// pathPrefix returns the first keys, segs, of path written in the same
+// notation as path.
+func pathPrefix(path string, segs []string) string {
+	if !strings.HasPrefix(path, "/") {
+		return strings.Join(segs, ".")
+	}
+	return jsonPointer(segs)
+}
+
+// Exists reports whether there is a value at p.
+func (p Path) Exists() bool {
+	_, ok := p.view.m[p.key]
+	return ok
+}
+
+// Value returns the value at p as decoded from the config, or nil if there
+// is none. Nested objects are of type map[string]interface{}, lists of type
+// []interface{}, numbers of type json.Number and values marked with _secret
+// of type Secret.
+func (p Path) Value() interface{} {
+	return p.view.m[p.key]
+}
+
+func (p Path) RequiredObject() Obj                   { return p.view.RequiredObject(p.key) }
+func (p Path) OptionalObject() Obj                   { return p.view.OptionalObject(p.key) }
+func (p Path) RequiredString() string                { return p.view.RequiredString(p.key) }
+func (p Path) OptionalString(def string) string      { return p.view.OptionalString(p.key, def) }
+func (p Path) RequiredStringOrObject() interface{}   { return p.view.RequiredStringOrObject(p.key) }
+func (p Path) OptionalStringOrObject() interface{}   { return p.view.OptionalStringOrObject(p.key) }
+func (p Path) RequiredBool() bool                    { return p.view.RequiredBool(p.key) }
+func (p Path) OptionalBool(def bool) bool            { return p.view.OptionalBool(p.key, def) }
+func (p Path) RequiredInt() int                      { return p.view.RequiredInt(p.key) }
+func (p Path) OptionalInt(def int) int               { return p.view.OptionalInt(p.key, def) }
+func (p Path) RequiredUint() uint                    { return p.view.RequiredUint(p.key) }
+func (p Path) OptionalUint(def uint) uint            { return p.view.OptionalUint(p.key, def) }
+func (p Path) RequiredInt64() int64                  { return p.view.RequiredInt64(p.key) }
+func (p Path) OptionalInt64(def int64) int64         { return p.view.OptionalInt64(p.key, def) }
+func (p Path) RequiredFloat() float64                { return p.view.RequiredFloat(p.key) }
+func (p Path) OptionalFloat(def float64) float64     { return p.view.OptionalFloat(p.key, def) }
+func (p Path) RequiredList() []string                { return p.view.RequiredList(p.key) }
+func (p Path) OptionalList() []string                { return p.view.OptionalList(p.key) }
+func (p Path) RequiredIntList() []int64              { return p.view.RequiredIntList(p.key) }
+func (p Path) OptionalIntList() []int64              { return p.view.OptionalIntList(p.key) }
+func (p Path) RequiredFloatList() []float64          { return p.view.RequiredFloatList(p.key) }
+func (p Path) OptionalFloatList() []float64          { return p.view.OptionalFloatList(p.key) }
+func (p Path) RequiredBoolList() []bool              { return p.view.RequiredBoolList(p.key) }
+func (p Path) OptionalBoolList() []bool              { return p.view.OptionalBoolList(p.key) }
+func (p Path) RequiredDurationList() []time.Duration { return p.view.RequiredDurationList(p.key) }
+func (p Path) OptionalDurationList() []time.Duration { return p.view.OptionalDurationList(p.key) }
+func (p Path) RequiredObjectList() []Obj             { return p.view.RequiredObjectList(p.key) }
+func (p Path) OptionalObjectList() []Obj             { return p.view.OptionalObjectList(p.key) }
+func (p Path) RequiredDuration() time.Duration       { return p.view.RequiredDuration(p.key) }
+func (p Path) OptionalDuration(def time.Duration) time.Duration {
+	return p.view.OptionalDuration(p.key, def)
+}
+func (p Path) RequiredTime() time.Time              { return p.view.RequiredTime(p.key) }
+func (p Path) OptionalTime(def time.Time) time.Time { return p.view.OptionalTime(p.key, def) }
+func (p Path) RequiredTimeLayout(layout string) time.Time {
+	return p.view.RequiredTimeLayout(p.key, layout)
+}
+func (p Path) OptionalTimeLayout(layout string, def time.Time) time.Time {
+	return p.view.OptionalTimeLayout(p.key, layout, def)
+}
+func (p Path) RequiredSize() int64                          { return p.view.RequiredSize(p.key) }
+func (p Path) OptionalSize(def int64) int64                 { return p.view.OptionalSize(p.key, def) }
+func (p Path) RequiredURL() *url.URL                        { return p.view.RequiredURL(p.key) }
+func (p Path) OptionalURL(def *url.URL) *url.URL            { return p.view.OptionalURL(p.key, def) }
+func (p Path) RequiredHostPort() HostPort                   { return p.view.RequiredHostPort(p.key) }
+func (p Path) OptionalHostPort(def HostPort) HostPort       { return p.view.OptionalHostPort(p.key, def) }
+func (p Path) RequiredIP() netip.Addr                       { return p.view.RequiredIP(p.key) }
+func (p Path) OptionalIP(def netip.Addr) netip.Addr         { return p.view.OptionalIP(p.key, def) }
+func (p Path) RequiredIPList() []netip.Addr                 { return p.view.RequiredIPList(p.key) }
+func (p Path) OptionalIPList() []netip.Addr                 { return p.view.OptionalIPList(p.key) }
+func (p Path) RequiredPrefix() netip.Prefix                 { return p.view.RequiredPrefix(p.key) }
+func (p Path) OptionalPrefix(def netip.Prefix) netip.Prefix { return p.view.OptionalPrefix(p.key, def) }
+func (p Path) RequiredPrefixList() []netip.Prefix           { return p.view.RequiredPrefixList(p.key) }
+func (p Path) OptionalPrefixList() []netip.Prefix           { return p.view.OptionalPrefixList(p.key) }
+func (p Path) RequiredRegexp() *regexp.Regexp               { return p.view.RequiredRegexp(p.key) }
+func (p Path) OptionalRegexp(def *regexp.Regexp) *regexp.Regexp {
+	return p.view.OptionalRegexp(p.key, def)
+}
+func (p Path) RequiredStringMap() map[string]string { return p.view.RequiredStringMap(p.key) }
+func (p Path) OptionalStringMap() map[string]string { return p.view.OptionalStringMap(p.key) }
+func (p Path) RequiredInt64Map() map[string]int64   { return p.view.RequiredInt64Map(p.key) }
+func (p Path) OptionalInt64Map() map[string]int64   { return p.view.OptionalInt64Map(p.key) }
+func (p Path) RequiredObjectMap() map[string]Obj    { return p.view.RequiredObjectMap(p.key) }
+func (p Path) OptionalObjectMap() map[string]Obj    { return p.view.OptionalObjectMap(p.key) }
@@ -0,0 +1,87 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAt(t *testing.T) {
+	obj, err := ReadFile("testdata/path.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := obj.At("database.primary.port").RequiredInt(); g != 5432 {
+		t.Errorf("port = %d; want 5432", g)
+	}
+	if g := obj.At("/database/replicas/0/host").RequiredString(); g != "db2" {
+		t.Errorf("replica host = %q; want db2", g)
+	}
+	if g := obj.At("database.replicas.1.host").RequiredString(); g != "db3" {
+		t.Errorf("replica host = %q; want db3", g)
+	}
+	if g := obj.At("/a~1b/c~0d").RequiredBool(); !g {
+		t.Error("/a~1b/c~0d = false; want true")
+	}
+	if g := obj.At("database.primary.timeout").OptionalDuration(5); g != 5 {
+		t.Errorf("timeout = %v; want the default", g)
+	}
+	if !obj.At("database.primary").Exists() || obj.At("database.standby").Exists() || obj.At("database.replicas.2").Exists() {
+		t.Error("Exists is wrong")
+	}
+	if g := obj.At("").RequiredObject().RequiredString("name"); g != "svc" {
+		t.Errorf("name = %q; want svc", g)
+	}
+
+	obj.At("database.replicas.1.port").RequiredInt()
+	obj.At("database.replicas.2.port").RequiredInt()
+	obj.At("/database/primary/user").RequiredString()
+	obj.At("name.first").OptionalString("")
+	obj.At("/a~2b").RequiredBool()
+
+	var merr MultiError
+	if err := obj.Validate(); !errors.As(err, &merr) {
+		t.Fatalf("Validate = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Expected config key "database.replicas.1.port" to be a number, not string`,
+		`Missing required config key "database.replicas.2.port" (integer)`,
+		`Missing required config key "/database/primary/user" (string)`,
+		`Expected config key "name" to be an object or list, not string`,
+		`invalid JSON Pointer "/a~2b": bad escape in "a~2b"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
+
+func TestAtKnownKeys(t *testing.T) {
+	obj, err := ReadFile("testdata/path.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.At("database.primary.host").RequiredString()
+	obj.At("name").RequiredString()
+	err = obj.ValidateAll()
+	var merr MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("ValidateAll = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Unknown key "a/b"`,
+		`Unknown key "database.replicas"`,
+		`Unknown key "database.primary.port"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package jsoncfgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Position is the place in a config file where a value was written.
+// Line and Column are 1-based; Column counts bytes. The position of a
+// value produced by an expression, such as ["_env", "HOST"], is that of
+// the expression.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Position returns the position of the value at path in the config read
+// by the last call to ReadFile or ReadFiles. The path is a JSON Pointer
+// or a dotted path, as accepted by Obj.At. The values of files included
+// with _fileobj have their position in the included file. With ReadFiles,
+// a value has the position of the file that set it last.
+func (c *ConfigParser) Position(path string) (Position, bool) {
+	segs, err := splitPath(path)
+	if err != nil {
+		return Position{}, false
+	}
+	o, ok := c.positions[jsonPointer(segs)]
+	return o.Position, ok
+}
+
+// jsonPointer returns the JSON Pointer made of the keys segs.
+func jsonPointer(segs []string) string {
+	var b strings.Builder
+	for _, seg := range segs {
+		b.WriteByte('/')
+		pointerEscaper.WriteString(&b, seg)
+	}
+	return b.String()
+}
+
+// recordPositions records in c.filePositions the position of every value
+// of the config file name, whose contents data were translated to the
+// strict JSON src using offsets in relaxed mode. The pointers are prefixed
+// with base, the pointer of the file in the config. The root of an
+// included file is not recorded, so that it keeps the position of its
+// _fileobj expression.
+func (c *ConfigParser) recordPositions(name string, data, src []byte, offsets []int64, base string) {
+	var lines []int // the offsets of the line starts of data
+	lines = append(lines, 0)
+	for i, b := range data {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	valueOffsets(src, func(ptr string, off int64) {
+		if ptr == "" && base != "" {
+			return
+		}
+		if offsets != nil && off < int64(len(offsets)) {
+			off = offsets[off]
+		}
+		line := sort.Search(len(lines), func(i int) bool { return int64(lines[i]) > off })
+		c.filePositions[base+ptr] = Origin{Position: Position{
+			File:   name,
+			Line:   line,
+			Column: int(off) - lines[line-1] + 1,
+		}}
+	})
+}
+
+// valueOffsets calls fn with the JSON Pointer and offset in src of every
+// value of the JSON document src, which must be valid.
+func valueOffsets(src []byte, fn func(ptr string, off int64)) {
+	type frame struct {
+		ptr     string
+		obj     bool
+		wantKey bool
+		key     string
+		n       int
+	}
+	var stack []frame
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	for {
+		off := dec.InputOffset()
+		for off < int64(len(src)) && strings.IndexByte(" \t\r\n,:", src[off]) >= 0 {
+			off++
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		ptr := ""
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			switch {
+			case top.obj && top.wantKey:
+				top.key, top.wantKey = tok.(string), false
+				continue
+			case top.obj:
+				ptr = top.ptr + "/" + pointerEscaper.Replace(top.key)
+				top.wantKey = true
+			default:
+				ptr = top.ptr + "/" + strconv.Itoa(top.n)
+				top.n++
+			}
+		}
+		fn(ptr, off)
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, frame{ptr: ptr, obj: true, wantKey: true})
+		case json.Delim('['):
+			stack = append(stack, frame{ptr: ptr})
+		}
+	}
+}
+
+// settlePositions returns the origins of the values of v taken from
+// recorded. A value without a recorded origin, such as one produced by
+// an expression, gets the origin of its parent. Origins recorded for
+// values that no longer exist are dropped.
+func settlePositions(v interface{}, recorded map[string]Origin) map[string]Origin {
+	settled := make(map[string]Origin, len(recorded))
+	var walk func(v interface{}, ptr string, parent Origin, known bool)
+	walk = func(v interface{}, ptr string, parent Origin, known bool) {
+		if o, ok := recorded[ptr]; ok {
+			parent, known = o, true
+		}
+		if known {
+			settled[ptr] = parent
+		}
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, sv := range t {
+				walk(sv, ptr+"/"+pointerEscaper.Replace(k), parent, known)
+			}
+		case []interface{}:
+			for i, sv := range t {
+				walk(sv, ptr+"/"+strconv.Itoa(i), parent, known)
+			}
+		}
+	}
+	walk(v, "", Origin{}, false)
+	return settled
+}
+
+// underPointer reports whether p is ptr or a pointer into the value at ptr.
+func underPointer(p, ptr string) bool {
+	return p == ptr || strings.HasPrefix(p, ptr+"/")
+}
+
+// movePositions replaces the origins of the merged value at dst with
+// those of the value at src in the file being merged.
+func (c *ConfigParser) movePositions(dst, src string) {
+	for p := range c.positions {
+		if underPointer(p, dst) {
+			delete(c.positions, p)
+		}
+	}
+	for p, pos := range c.filePositions {
+		if underPointer(p, src) {
+			c.positions[dst+p[len(src):]] = pos
+		}
+	}
+}
+
+// renumberPositions moves the origins of the elements of the merged
+// list at ptr to their new index, as given by index, dropping those of the
+// elements missing from index.
+func (c *ConfigParser) renumberPositions(ptr string, index map[int]int) {
+	moved := make(map[string]Origin)
+	for p, pos := range c.positions {
+		rest, ok := strings.CutPrefix(p, ptr+"/")
+		if !ok {
+			continue
+		}
+		delete(c.positions, p)
+		seg, tail := rest, ""
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			seg, tail = rest[:i], rest[i:]
+		}
+		i, _ := strconv.Atoi(seg)
+		if n, ok := index[i]; ok {
+			moved[ptr+"/"+strconv.Itoa(n)+tail] = pos
+		}
+	}
+	for p, pos := range moved {
+		c.positions[p] = pos
+	}
+}
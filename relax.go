@@ -0,0 +1,164 @@
+package jsoncfgo
+
+// A relaxError reports malformed input found while translating a relaxed
+// config file, at the given offset of the original file.
+type relaxError struct {
+	msg    string
+	offset int64
+}
+
+func (e *relaxError) Error() string { return e.msg }
+
+// relaxJSON translates the JSON superset accepted by ConfigParser in
+// relaxed mode into strict JSON: line (//) and block (/* */) comments are
+// removed, as are trailing commas before a closing bracket or brace, and
+// unquoted object keys made of letters, digits, '_' and '$' are quoted.
+//
+// Along with the translation it returns, for each of its bytes, the offset
+// in data of the byte it came from, so that syntax errors found in the
+// translation can be reported against the original file.
+func relaxJSON(data []byte) ([]byte, []int64, error) {
+	out := make([]byte, 0, len(data))
+	offsets := make([]int64, 0, len(data))
+	emit := func(b byte, at int) {
+		out = append(out, b)
+		offsets = append(offsets, int64(at))
+	}
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b == '"':
+			end, err := skipString(data, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			for ; i < end; i++ {
+				emit(data[i], i)
+			}
+		case b == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			end, err := skipComment(data, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			i = end
+		case b == ',':
+			// Only a comma following a value is dropped, so that [,] and
+			// {,} are still rejected.
+			if next := skipSpace(data, i+1); next < len(data) && (data[next] == '}' || data[next] == ']') && followsValue(out) {
+				i++
+				continue
+			}
+			emit(b, i)
+			i++
+		case isIdentStart(b):
+			end := i + 1
+			for end < len(data) && isIdentChar(data[end]) {
+				end++
+			}
+			if next := skipSpace(data, end); next < len(data) && data[next] == ':' {
+				emit('"', i)
+				for ; i < end; i++ {
+					emit(data[i], i)
+				}
+				emit('"', end-1)
+				continue
+			}
+			for ; i < end; i++ {
+				emit(data[i], i)
+			}
+		default:
+			emit(b, i)
+			i++
+		}
+	}
+	return out, offsets, nil
+}
+
+// followsValue reports whether the last byte of out other than white space
+// ends a value, rather than opening a list or object or separating two of
+// its elements.
+func followsValue(out []byte) bool {
+	for i := len(out) - 1; i >= 0; i-- {
+		switch out[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[', '{', ',', ':':
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// relaxedOffset maps offset, as reported by a json.SyntaxError for the
+// translation produced by relaxJSON, back to the original file.
+func relaxedOffset(offsets []int64, offset int64) int64 {
+	if offset <= 0 || len(offsets) == 0 {
+		return 0
+	}
+	if offset > int64(len(offsets)) {
+		offset = int64(len(offsets))
+	}
+	return offsets[offset-1] + 1
+}
+
+// skipString returns the offset just past the string starting at data[i].
+func skipString(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, &relaxError{"unterminated string", int64(i)}
+}
+
+// skipComment returns the offset just past the comment starting at data[i].
+func skipComment(data []byte, i int) (int, error) {
+	if data[i+1] == '/' {
+		j := i + 2
+		for j < len(data) && data[j] != '\n' {
+			j++
+		}
+		return j, nil
+	}
+	for j := i + 2; j+1 < len(data); j++ {
+		if data[j] == '*' && data[j+1] == '/' {
+			return j + 2, nil
+		}
+	}
+	return 0, &relaxError{"unterminated block comment", int64(i)}
+}
+
+// skipSpace returns the offset of the first byte at or after data[i]
+// that is neither white space nor part of a comment.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+			continue
+		case '/':
+			if i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*') {
+				end, err := skipComment(data, i)
+				if err != nil {
+					return len(data)
+				}
+				i = end
+				continue
+			}
+		}
+		return i
+	}
+	return i
+}
+
+func isIdentStart(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_' || b == '$'
+}
+
+func isIdentChar(b byte) bool {
+	return isIdentStart(b) || b >= '0' && b <= '9'
+}
@@ -0,0 +1,551 @@
+package jsoncfgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrSchema is the kind of SchemaError.
+var ErrSchema = errors.New("config value violates its schema")
+
+// A Schema is a JSON Schema against which an evaluated config can be
+// validated, as returned by ParseSchema.
+//
+// It supports the core keywords of JSON Schema draft 2020-12 used to
+// describe configs: type, properties, required, enum, const, minimum,
+// maximum, pattern, items, prefixItems, minItems, maxItems,
+// patternProperties, additionalProperties, anyOf and $ref. A $ref must point into
+// the same document, as in "#/$defs/server". Patterns use the syntax of
+// package regexp rather than that of ECMA 262. Other keywords, such as
+// title and description, are ignored.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+	checked  map[string]bool // the references whose targets were checked
+}
+
+// schemaTypes are the values of the type keyword.
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// ParseSchema parses the JSON Schema document data.
+func ParseSchema(data []byte) (*Schema, error) {
+	var root interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("jsoncfgo: invalid schema: %w", err)
+	}
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp), checked: make(map[string]bool)}
+	if err := s.check(root, ""); err != nil {
+		return nil, fmt.Errorf("jsoncfgo: invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// LoadSchema reads and parses the JSON Schema file at path.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// check checks the keywords of the schema v at pointer ptr of the schema
+// document, compiling its patterns. The schemas referenced with $ref are
+// checked as well, wherever they are in the document.
+func (s *Schema) check(v interface{}, ptr string) error {
+	if _, ok := v.(bool); ok {
+		return nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%q: a schema must be an object or a boolean", ptr)
+	}
+	for _, kw := range sortedKeys(m) {
+		kv := m[kw]
+		kwPtr := ptr + "/" + pointerEscaper.Replace(kw)
+		var err error
+		switch kw {
+		case "type":
+			err = checkTypeKeyword(kv)
+		case "required":
+			l, ok := kv.([]interface{})
+			if !ok {
+				err = errors.New("must be a list of strings")
+			}
+			for _, e := range l {
+				if _, ok := e.(string); !ok {
+					err = errors.New("must be a list of strings")
+				}
+			}
+		case "enum":
+			if _, ok := kv.([]interface{}); !ok {
+				err = errors.New("must be a list")
+			}
+		case "minimum", "maximum":
+			if _, ok := kv.(json.Number); !ok {
+				err = errors.New("must be a number")
+			}
+		case "pattern":
+			p, ok := kv.(string)
+			if !ok {
+				err = errors.New("must be a string")
+				break
+			}
+			s.patterns[p], err = regexp.Compile(p)
+		case "items", "additionalProperties":
+			if err := s.check(kv, kwPtr); err != nil {
+				return err
+			}
+		case "minItems", "maxItems":
+			if n, ok := kv.(json.Number); !ok {
+				err = errors.New("must be an integer")
+			} else if _, err = n.Int64(); err != nil {
+				err = errors.New("must be an integer")
+			}
+		case "anyOf", "prefixItems":
+			l, ok := kv.([]interface{})
+			if !ok || len(l) == 0 && kw == "anyOf" {
+				err = errors.New("must be a non-empty list of schemas")
+				break
+			}
+			for i, e := range l {
+				if err := s.check(e, kwPtr+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		case "properties", "patternProperties", "$defs":
+			sm, ok := kv.(map[string]interface{})
+			if !ok {
+				err = errors.New("must be an object")
+				break
+			}
+			for _, k := range sortedKeys(sm) {
+				if kw == "patternProperties" {
+					if s.patterns[k], err = regexp.Compile(k); err != nil {
+						return fmt.Errorf("%q: %w", kwPtr, err)
+					}
+				}
+				if err = s.check(sm[k], kwPtr+"/"+pointerEscaper.Replace(k)); err != nil {
+					return err
+				}
+			}
+		case "$ref":
+			ref, ok := kv.(string)
+			if !ok {
+				err = errors.New("must be a string")
+				break
+			}
+			var target interface{}
+			if target, err = s.resolve(ref); err != nil || s.checked[ref] {
+				break
+			}
+			s.checked[ref] = true
+			if err := s.check(target, strings.TrimPrefix(ref, "#")); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%q: %w", kwPtr, err)
+		}
+	}
+	return nil
+}
+
+func checkTypeKeyword(v interface{}) error {
+	names := []interface{}{v}
+	if l, ok := v.([]interface{}); ok {
+		names = l
+	}
+	for _, n := range names {
+		if name, ok := n.(string); !ok || !schemaTypes[name] {
+			return fmt.Errorf("unknown type %v", n)
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema referenced by ref, a JSON Pointer fragment
+// of the schema document.
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	frag, ok := strings.CutPrefix(ref, "#")
+	if !ok || frag != "" && !strings.HasPrefix(frag, "/") {
+		return nil, fmt.Errorf("unsupported reference %q", ref)
+	}
+	segs, err := splitPath(frag)
+	if err != nil {
+		return nil, err
+	}
+	v := s.root
+	for _, seg := range segs {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v, ok = t[seg]
+		case []interface{}:
+			var n int
+			n, err = strconv.Atoi(seg)
+			ok = err == nil && n >= 0 && n < len(t)
+			if ok {
+				v = t[n]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %q", ref)
+		}
+	}
+	switch v.(type) {
+	case bool, map[string]interface{}:
+		return v, nil
+	}
+	return nil, fmt.Errorf("reference %q is not a schema", ref)
+}
+
+// A SchemaError reports a value of a config that violates its schema.
+type SchemaError struct {
+	Pointer  string   // the JSON Pointer of the value in the config
+	Position Position // the position of the value, if known
+	Keyword  string   // the keyword violated, such as "required", or "false"
+	Err      error    // the violation, such as "must be at most 65535"
+}
+
+func (e *SchemaError) Error() string {
+	msg := fmt.Sprintf("Config value at %q %v", e.Pointer, e.Err)
+	if e.Position.File == "" {
+		return msg
+	}
+	return e.Position.String() + ": " + msg
+}
+
+func (e *SchemaError) Unwrap() error { return e.Err }
+
+func (e *SchemaError) Is(target error) bool { return target == ErrSchema }
+
+// Validate validates v, a config or any value decoded from JSON, against
+// s. It returns nil, a SchemaError, or a MultiError holding a SchemaError
+// for each violation found, in the order of the keys of the objects.
+// The errors do not have positions; see ConfigParser.ValidateSchema.
+func (s *Schema) Validate(v interface{}) error {
+	if o, ok := v.(Obj); ok {
+		v = o.m
+	}
+	var errs []error
+	s.validate(s.root, v, "", nil, &errs)
+	return joinErrors(errs)
+}
+
+// ValidateSchema validates the config read by the last call to ReadFile
+// or ReadFiles against s, like Schema.Validate, giving each error the
+// position in the config files of the value it reports.
+func (c *ConfigParser) ValidateSchema(s *Schema) error {
+	var errs []error
+	s.validate(s.root, c.rootJSON, "", nil, &errs)
+	for _, err := range errs {
+		e := err.(*SchemaError)
+		e.Position = c.positions[e.Pointer].Position
+	}
+	return joinErrors(errs)
+}
+
+// validate appends to errs the violations of schema by the value v at
+// pointer ptr. refs holds the references followed at ptr, to stop cycles.
+// A Secret is validated as the value it holds.
+func (s *Schema) validate(schema, v interface{}, ptr string, refs []string, errs *[]error) {
+	v = reveal(v)
+	fail := func(ptr, kw, format string, args ...interface{}) {
+		*errs = append(*errs, &SchemaError{Pointer: ptr, Keyword: kw, Err: fmt.Errorf(format, args...)})
+	}
+	m, ok := schema.(map[string]interface{})
+	if !ok {
+		if schema == false {
+			fail(ptr, "false", "is not allowed")
+		}
+		return
+	}
+	if ref, ok := m["$ref"].(string); ok {
+		for _, r := range refs {
+			if r == ref {
+				fail(ptr, "$ref", "has a reference cycle through %q", ref)
+				return
+			}
+		}
+		if target, err := s.resolve(ref); err == nil {
+			s.validate(target, v, ptr, append(refs, ref), errs)
+		}
+	}
+	if alts, ok := m["anyOf"].([]interface{}); ok {
+		s.validateAnyOf(alts, v, ptr, refs, errs)
+	}
+	if typ, ok := m["type"]; ok && !hasType(v, typ) {
+		fail(ptr, "type", "must be of type %s, not %s", typeNames(typ), jsonType(v))
+		// The other keywords would only report the same mistake.
+		return
+	}
+	if c, ok := m["const"]; ok && !jsonEqual(v, c) {
+		b, _ := json.Marshal(c)
+		fail(ptr, "const", "must be %s", b)
+	}
+	if enum, ok := m["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			strs := make([]string, len(enum))
+			for i, e := range enum {
+				b, _ := json.Marshal(e)
+				strs[i] = string(b)
+			}
+			fail(ptr, "enum", "must be one of %s", strings.Join(strs, ", "))
+		}
+	}
+	if n, ok := jsonRat(v); ok {
+		if min, ok := m["minimum"].(json.Number); ok {
+			if r, _ := jsonRat(min); n.Cmp(r) < 0 {
+				fail(ptr, "minimum", "must be at least %s", min)
+			}
+		}
+		if max, ok := m["maximum"].(json.Number); ok {
+			if r, _ := jsonRat(max); n.Cmp(r) > 0 {
+				fail(ptr, "maximum", "must be at most %s", max)
+			}
+		}
+	}
+	if str, ok := v.(string); ok {
+		if p, ok := m["pattern"].(string); ok && !s.patterns[p].MatchString(str) {
+			fail(ptr, "pattern", "must match %q", p)
+		}
+	}
+	switch t := v.(type) {
+	case map[string]interface{}:
+		props, _ := m["properties"].(map[string]interface{})
+		patternProps, _ := m["patternProperties"].(map[string]interface{})
+		if req, ok := m["required"].([]interface{}); ok {
+			for _, r := range req {
+				name, ok := r.(string)
+				if !ok {
+					continue
+				}
+				if _, ok := t[name]; !ok {
+					fail(ptr, "required", "is missing required property %q", name)
+				}
+			}
+		}
+		for _, k := range sortedKeys(t) {
+			kPtr := ptr + "/" + pointerEscaper.Replace(k)
+			matched := false
+			if ps, ok := props[k]; ok {
+				s.validate(ps, t[k], kPtr, nil, errs)
+				matched = true
+			}
+			for _, p := range sortedKeys(patternProps) {
+				if s.patterns[p].MatchString(k) {
+					s.validate(patternProps[p], t[k], kPtr, nil, errs)
+					matched = true
+				}
+			}
+			if matched {
+				continue
+			}
+			if ap, ok := m["additionalProperties"]; ok {
+				if ap == false {
+					fail(kPtr, "additionalProperties", "is not an allowed property")
+					continue
+				}
+				s.validate(ap, t[k], kPtr, nil, errs)
+			}
+		}
+	case []interface{}:
+		if min, ok := m["minItems"].(json.Number); ok {
+			if n, _ := min.Int64(); int64(len(t)) < n {
+				fail(ptr, "minItems", "must have at least %d items", n)
+			}
+		}
+		if max, ok := m["maxItems"].(json.Number); ok {
+			if n, _ := max.Int64(); int64(len(t)) > n {
+				fail(ptr, "maxItems", "must have at most %d items", n)
+			}
+		}
+		prefix, _ := m["prefixItems"].([]interface{})
+		for i, e := range t {
+			if i < len(prefix) {
+				s.validate(prefix[i], e, ptr+"/"+strconv.Itoa(i), nil, errs)
+			} else if items, ok := m["items"]; ok {
+				s.validate(items, e, ptr+"/"+strconv.Itoa(i), nil, errs)
+			}
+		}
+	}
+}
+
+// validateAnyOf appends to errs the violations of the anyOf keyword, alts,
+// by the value v at pointer ptr. If v matches none of alts, but has the
+// type of only one of them, the violations of that one are reported, as
+// the others are unlikely to be what was meant. If it has the type of
+// none of them, the type error of the first one is reported.
+func (s *Schema) validateAnyOf(alts []interface{}, v interface{}, ptr string, refs []string, errs *[]error) {
+	var candidates [][]error
+	var typeErr error
+	for _, alt := range alts {
+		var altErrs []error
+		s.validate(alt, v, ptr, refs, &altErrs)
+		if len(altErrs) == 0 {
+			return
+		}
+		if len(altErrs) == 1 {
+			if e := altErrs[0].(*SchemaError); e.Keyword == "type" && e.Pointer == ptr {
+				if typeErr == nil {
+					typeErr = e
+				}
+				continue
+			}
+		}
+		candidates = append(candidates, altErrs)
+	}
+	switch len(candidates) {
+	case 0:
+		*errs = append(*errs, typeErr)
+		return
+	case 1:
+		*errs = append(*errs, candidates[0]...)
+		return
+	}
+	*errs = append(*errs, &SchemaError{Pointer: ptr, Keyword: "anyOf", Err: errors.New("does not match any of the allowed schemas")})
+}
+
+// jsonType returns the JSON Schema type of the decoded JSON value v.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if _, ok := jsonRat(v); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// hasType reports whether v has one of the types of the type keyword typ.
+func hasType(v interface{}, typ interface{}) bool {
+	names := []interface{}{typ}
+	if l, ok := typ.([]interface{}); ok {
+		names = l
+	}
+	for _, n := range names {
+		switch n {
+		case jsonType(v):
+			return true
+		case "integer":
+			if r, ok := jsonRat(v); ok && r.IsInt() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeNames formats the type keyword typ for an error message.
+func typeNames(typ interface{}) string {
+	l, ok := typ.([]interface{})
+	if !ok {
+		return fmt.Sprint(typ)
+	}
+	strs := make([]string, len(l))
+	for i, n := range l {
+		strs[i] = fmt.Sprint(n)
+	}
+	return strings.Join(strs, " or ")
+}
+
+// jsonRat returns the exact value of the JSON number v.
+func jsonRat(v interface{}) (*big.Rat, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(string(t))
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(t) == nil {
+			return nil, false
+		}
+		return r, true
+	case int, int64, uint, uint64:
+		n, err := parseInteger(t)
+		if err != nil {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(n), true
+	}
+	return nil, false
+}
+
+// jsonEqual reports whether the decoded JSON values a and b are equal,
+// comparing numbers by value, as the enum keyword requires.
+func jsonEqual(a, b interface{}) bool {
+	if ra, ok := jsonRat(a); ok {
+		rb, ok := jsonRat(b)
+		return ok && ra.Cmp(rb) == 0
+	}
+	switch ta := a.(type) {
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for k, va := range ta {
+			vb, ok := tb[k]
+			if !ok || !jsonEqual(va, vb) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for i := range ta {
+			if !jsonEqual(ta[i], tb[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,210 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	s, err := LoadSchema("testdata/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c ConfigParser
+	if _, err := c.ReadFile("testdata/schema_bad.json"); err != nil {
+		t.Fatal(err)
+	}
+	var merr MultiError
+	if err := c.ValidateSchema(s); !errors.As(err, &merr) {
+		t.Fatalf("ValidateSchema = %v; want a MultiError", err)
+	}
+	want := []string{
+		`testdata/schema_bad.json:7:5: Config value at "/backends/1" is missing required property "host"`,
+		`testdata/schema_bad.json:7:14: Config value at "/backends/1/port" must be of type integer, not number`,
+		`testdata/schema_bad.json:7:26: Config value at "/backends/1/tls" is not an allowed property`,
+		`testdata/schema_bad.json:9:9: Config value at "/db" is missing required property "user"`,
+		`testdata/schema_db.json:2:11: Config value at "/db/pool" must be at least 1`,
+		`testdata/schema_bad.json:10:12: Config value at "/extra" is not an allowed property`,
+		`testdata/schema_bad.json:3:11: Config value at "/mode" must be one of "dev", "prod"`,
+		`testdata/schema_bad.json:2:11: Config value at "/name" must match "^[a-z][a-z0-9-]*$"`,
+		`testdata/schema_bad.json:4:43: Config value at "/server/port" must be at most 65535`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+		if !errors.Is(merr[i], ErrSchema) {
+			t.Errorf("error %d is not ErrSchema", i)
+		}
+	}
+	var serr *SchemaError
+	if errors.As(merr[4], &serr); serr.Keyword != "minimum" || serr.Pointer != "/db/pool" {
+		t.Errorf("error 4 = %+v; want a minimum error at /db/pool", serr)
+	}
+
+	c = ConfigParser{Relaxed: true}
+	if _, err := c.ReadFile("testdata/schema_good.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ValidateSchema(s); err != nil {
+		t.Errorf("ValidateSchema = %v; want nil", err)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	c := ConfigParser{Relaxed: true}
+	if _, err := c.ReadFile("testdata/relaxed.json"); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"":                  "testdata/relaxed.json:2:1",
+		"host":              "testdata/relaxed.json:5:9",
+		"/list/1":           "testdata/relaxed.json:10:5",
+		"nested.inner_key":  "testdata/relaxed.json:12:23",
+		"/nested/inner_key": "testdata/relaxed.json:12:23",
+	} {
+		if pos, ok := c.Position(path); !ok || pos.String() != want {
+			t.Errorf("Position(%q) = %v, %v; want %s", path, pos, ok, want)
+		}
+	}
+	if pos, ok := c.Position("list.2"); ok {
+		t.Errorf("Position(list.2) = %v; want none", pos)
+	}
+
+	c = ConfigParser{
+		ListStrategies: map[string]ListStrategy{"upstreams": ListMergeByKey},
+	}
+	t.Setenv("TEST_LAYER_HOST", "web1")
+	if _, err := c.ReadFiles("testdata/layer_base.json", "testdata/layer_env.json", "testdata/layer_host.json"); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"host":               "testdata/layer_host.json:2:11",
+		"port":               "testdata/layer_env.json:2:11",
+		"db.user":            "testdata/layer_base.json:7:13",
+		"db.pool.min":        "testdata/layer_base.json:9:21",
+		"db.pool.max":        "testdata/layer_env.json:6:21",
+		"upstreams.1.url":    "testdata/layer_base.json:13:26",
+		"upstreams.1.weight": "testdata/layer_env.json:9:29",
+		"upstreams.2":        "testdata/layer_env.json:11:5",
+		"upstreams.2.url":    "testdata/layer_env.json:11:26",
+		"common.key":         "testdata/include2.json:2:10",
+		"extra.kept":         "testdata/layer_host.json:5:42",
+	} {
+		if pos, ok := c.Position(path); !ok || pos.String() != want {
+			t.Errorf("Position(%q) = %v, %v; want %s", path, pos, ok, want)
+		}
+	}
+	for _, path := range []string{"debug", "db.password", "upstreams.3", "extra.gone"} {
+		if pos, ok := c.Position(path); ok {
+			t.Errorf("Position(%q) = %v; want none", path, pos)
+		}
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := ParseSchema([]byte(`{
+		"$defs": {
+			"list": {"type": "array", "items": {"$ref": "#/$defs/list"}},
+			"loop": {"$ref": "#/$defs/loop"}
+		},
+		"properties": {
+			"n": {"type": ["integer", "null"], "enum": [1, 2.0, null]},
+			"l": {"$ref": "#/$defs/list"},
+			"loop": {"$ref": "#/$defs/loop"},
+			"none": false
+		},
+		"additionalProperties": {"type": "string"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		doc  string
+		want string
+	}{
+		{`{"n": 2, "l": [[], [[]]], "s": "x"}`, ""},
+		{`{"n": null}`, ""},
+		{`{"n": 3}`, `Config value at "/n" must be one of 1, 2.0, null`},
+		{`{"n": 1.5}`, `Config value at "/n" must be of type integer or null, not number`},
+		{`{"l": [[1]]}`, `Config value at "/l/0/0" must be of type array, not number`},
+		{`{"none": 1}`, `Config value at "/none" is not allowed`},
+		{`{"s": true}`, `Config value at "/s" must be of type string, not boolean`},
+		{`{"loop": 1}`, `Config value at "/loop" has a reference cycle through "#/$defs/loop"`},
+	} {
+		var v interface{}
+		d := json.NewDecoder(strings.NewReader(tt.doc))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Validate(v)
+		if g := errString(err); g != tt.want {
+			t.Errorf("Validate(%s) = %s; want %s", tt.doc, g, tt.want)
+		}
+	}
+}
+
+func TestSchemaDefinitions(t *testing.T) {
+	// Schemas outside of $defs are only checked through the references
+	// to them.
+	s, err := ParseSchema([]byte(`{
+		"definitions": {
+			"server": {"required": ["host"], "properties": {"host": {"pattern": "^[a-z.]+$"}}}
+		},
+		"properties": {"server": {"$ref": "#/definitions/server"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		doc  string
+		want string
+	}{
+		{`{"server": {"host": "db.example.com"}}`, ""},
+		{`{"server": {"host": "DB"}}`, `Config value at "/server/host" must match "^[a-z.]+$"`},
+		{`{"server": {}}`, `Config value at "/server" is missing required property "host"`},
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.doc), &v); err != nil {
+			t.Fatal(err)
+		}
+		err := s.Validate(v)
+		if g := errString(err); g != tt.want {
+			t.Errorf("Validate(%s) = %s; want %s", tt.doc, g, tt.want)
+		}
+	}
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	for _, tt := range []struct {
+		doc  string
+		want string
+	}{
+		{`[]`, `jsoncfgo: invalid schema: "": a schema must be an object or a boolean`},
+		{`{"type": "int"}`, `jsoncfgo: invalid schema: "/type": unknown type int`},
+		{`{"properties": {"a": {"pattern": "("}}}`, "jsoncfgo: invalid schema: \"/properties/a/pattern\": error parsing regexp: missing closing ): `(`"},
+		{`{"items": {"$ref": "#/$defs/x"}}`, `jsoncfgo: invalid schema: "/items/$ref": unresolved reference "#/$defs/x"`},
+		{`{"$ref": "other.json#/x"}`, `jsoncfgo: invalid schema: "/$ref": unsupported reference "other.json#/x"`},
+		{`{"minimum": "1"}`, `jsoncfgo: invalid schema: "/minimum": must be a number`},
+		{`{"$ref": "#/definitions/x", "definitions": {"x": {"pattern": "("}}}`, "jsoncfgo: invalid schema: \"/definitions/x/pattern\": error parsing regexp: missing closing ): `(`"},
+		{`{"items": {"$ref": "#/definitions/x"}, "definitions": {"x": {"required": [1]}}}`, `jsoncfgo: invalid schema: "/definitions/x/required": must be a list of strings`},
+	} {
+		_, err := ParseSchema([]byte(tt.doc))
+		if g := errString(err); g != tt.want {
+			t.Errorf("ParseSchema(%s) = %s\nwant %s", tt.doc, g, tt.want)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
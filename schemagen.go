@@ -0,0 +1,423 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// The schemas of the expressions accepted in place of a value, in the
+// $defs of the schemas made by GenerateSchema.
+var expressionSchemas = map[string]interface{}{
+	"_env": map[string]interface{}{
+		"description": `An environment variable: ["_env", "${VAR}"] or ["_env", "${VAR}", default].`,
+		"type":        "array",
+		"prefixItems": []interface{}{
+			map[string]interface{}{"const": "_env"},
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": []interface{}{"string", "boolean"}},
+		},
+		"minItems": 2,
+		"maxItems": 3,
+	},
+	"_fileobj": map[string]interface{}{
+		"description": `An object read from another config file: ["_fileobj", "path"].`,
+		"type":        "array",
+		"prefixItems": []interface{}{
+			map[string]interface{}{"const": "_fileobj"},
+			map[string]interface{}{"type": "string"},
+		},
+		"minItems": 2,
+		"maxItems": 2,
+	},
+	"_secret": map[string]interface{}{
+		"description": `A secret value, redacted when printed: ["_secret", value].`,
+		"type":        "array",
+		"prefixItems": []interface{}{
+			map[string]interface{}{"const": "_secret"},
+			true,
+		},
+		"minItems": 2,
+		"maxItems": 2,
+	},
+}
+
+// GenerateSchema returns a JSON Schema document, in draft 2020-12,
+// describing the config files that Decode reads into the struct, or
+// pointer to a struct, v.
+//
+// The schema follows the fields and tags that Decode uses: it gives the
+// type of each key, lists the keys with the "required" option as
+// required, and turns the default, enum, min, max and pattern tags into
+// the default, enum, minimum, maximum and pattern keywords. A min or max
+// tag on a duration field has no equivalent and is left out. A description
+// tag sets the description of the key:
+//
+//	Port int `jsoncfg:"port" default:"8080" max:"65535" description:"The port to listen on."`
+//
+// Keys not described by a field are not allowed, as with ValidateAll,
+// except for the comment keys starting with an underscore. Named struct
+// types are described in $defs. Since the schema describes the files
+// rather than the evaluated config, any scalar value may also be written
+// as a ["_secret", ...] expression, any string or boolean as an
+// ["_env", ...] expression, and any object as a ["_fileobj", ...]
+// expression.
+func GenerateSchema(v interface{}) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsoncfgo: GenerateSchema requires a struct or a pointer to a struct, not %T", v)
+	}
+	g := &schemaGen{
+		root:  t,
+		defs:  make(map[string]interface{}),
+		names: make(map[reflect.Type]string),
+	}
+	root, err := g.object(t)
+	if err != nil {
+		return nil, err
+	}
+	for name, s := range expressionSchemas {
+		g.defs[name] = s
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$defs"] = g.defs
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// A schemaGen generates the schema of a struct type.
+type schemaGen struct {
+	root  reflect.Type
+	defs  map[string]interface{}
+	names map[reflect.Type]string // the names in defs of the struct types
+}
+
+// object returns the schema of the objects decoded into the struct type t.
+func (g *schemaGen) object(t reflect.Type) (map[string]interface{}, error) {
+	props := make(map[string]interface{})
+	var required []string
+	if err := g.fields(t, props, &required); err != nil {
+		return nil, err
+	}
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           props,
+		"patternProperties":    map[string]interface{}{"^_": true}, // comments
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, nil
+}
+
+// ref returns a reference to the schema of the struct type t, adding it
+// to the $defs under the name of t the first time. The schema of an
+// unnamed struct type is returned as is.
+func (g *schemaGen) ref(t reflect.Type) (map[string]interface{}, error) {
+	if t == g.root {
+		return map[string]interface{}{"$ref": "#"}, nil
+	}
+	if t.Name() == "" {
+		return g.object(t)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		for n := 2; g.defs[name] != nil || expressionSchemas[name] != nil; n++ {
+			name = t.Name() + strconv.Itoa(n)
+		}
+		g.names[t] = name
+		// Reserve the name while t is described, for recursive types.
+		g.defs[name] = true
+		s, err := g.object(t)
+		if err != nil {
+			return nil, err
+		}
+		g.defs[name] = s
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}, nil
+}
+
+// fields adds the schemas of the fields of the struct type t to props,
+// and the keys of its required fields to required.
+func (g *schemaGen) fields(t reflect.Type, props map[string]interface{}, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if _, tagged := f.Tag.Lookup("jsoncfg"); !tagged {
+				if err := g.fields(f.Type, props, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		tag, ok := parseFieldTag(f)
+		if !ok {
+			continue
+		}
+		s, err := g.field(f.Type, tag)
+		if err != nil {
+			return fmt.Errorf("jsoncfgo: field %s.%s: %v", t.Name(), f.Name, err)
+		}
+		if desc, ok := f.Tag.Lookup("description"); ok {
+			s["description"] = desc
+		}
+		props[tag.key] = s
+		if tag.required {
+			*required = append(*required, tag.key)
+		}
+	}
+	return nil
+}
+
+// field returns the schema of the key read into a field of type ft with
+// the options tag.
+func (g *schemaGen) field(ft reflect.Type, tag fieldTag) (map[string]interface{}, error) {
+	var s map[string]interface{}
+	var err error
+	if ft.Kind() == reflect.Slice {
+		s, err = g.list(ft, tag)
+	} else {
+		s, err = g.value(ft, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tag.def != nil && !tag.required {
+		def, err := defaultValue(ft, tag)
+		if err != nil {
+			return nil, fmt.Errorf("bad default %q: %v", *tag.def, err)
+		}
+		if def != nil {
+			s["default"] = def
+		}
+	}
+	return s, nil
+}
+
+// value returns the schema of a value of type ft, other than a slice,
+// which may also be written as the expression that can stand for it.
+func (g *schemaGen) value(ft reflect.Type, tag fieldTag) (map[string]interface{}, error) {
+	var s map[string]interface{}
+	var err error
+	expr := "_fileobj"
+	switch {
+	case ft == objType:
+		s = map[string]interface{}{"type": "object"}
+	case ft.Kind() == reflect.Struct && ft != timeType:
+		s, err = g.ref(ft)
+	case ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct:
+		s, err = g.ref(ft.Elem())
+	default:
+		s, err = scalarSchema(ft, tag)
+		expr = "_env"
+	}
+	if err != nil {
+		return nil, err
+	}
+	if expr == "_fileobj" {
+		if err := constraintTags(ft, tag, nil); err != nil {
+			return nil, err
+		}
+	}
+	alts := []interface{}{s}
+	if expr == "_fileobj" {
+		alts = append(alts, map[string]interface{}{"$ref": "#/$defs/_fileobj"})
+	} else {
+		// An _env expression evaluates to a string or a boolean, which
+		// the number accessors reject.
+		if t := s["type"]; t != "integer" && t != "number" {
+			alts = append(alts, map[string]interface{}{"$ref": "#/$defs/_env"})
+		}
+		alts = append(alts, map[string]interface{}{"$ref": "#/$defs/_secret"})
+	}
+	return map[string]interface{}{"anyOf": alts}, nil
+}
+
+// list returns the schema of a list read into the slice type ft.
+func (g *schemaGen) list(ft reflect.Type, tag fieldTag) (map[string]interface{}, error) {
+	if err := constraintTags(ft, tag, nil); err != nil {
+		return nil, err
+	}
+	et := ft.Elem()
+	var items map[string]interface{}
+	var err error
+	switch {
+	case et == objType, et.Kind() == reflect.Struct && et != timeType:
+		items, err = g.value(et, fieldTag{})
+	case et == durationType, et.Kind() == reflect.String, et.Kind() == reflect.Int64,
+		et.Kind() == reflect.Float64, et.Kind() == reflect.Bool:
+		items, err = g.value(et, fieldTag{layout: tag.layout})
+	default:
+		return nil, fmt.Errorf("unsupported type %v", ft)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type": "array", "items": items}, nil
+}
+
+// scalarSchema returns the schema of a value of the type t, which is
+// neither a struct nor a slice, read with the options tag.
+func scalarSchema(t reflect.Type, tag fieldTag) (map[string]interface{}, error) {
+	s := make(map[string]interface{})
+	switch {
+	case t == durationType:
+		s["type"] = "string"
+	case t == timeType:
+		s["type"] = "string"
+		if tag.layout == time.RFC3339 {
+			s["format"] = "date-time"
+		}
+	case tag.size:
+		if k := t.Kind(); k != reflect.Int && k != reflect.Int64 {
+			return nil, fmt.Errorf("size option on unsupported type %v", t)
+		}
+		s["type"] = []interface{}{"integer", "string"}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			s["type"] = "string"
+		case reflect.Bool:
+			s["type"] = "boolean"
+		case reflect.Int, reflect.Int64:
+			s["type"] = "integer"
+		case reflect.Uint:
+			s["type"] = "integer"
+			s["minimum"] = 0
+		case reflect.Float64:
+			s["type"] = "number"
+		default:
+			return nil, fmt.Errorf("unsupported type %v", t)
+		}
+	}
+	if err := constraintTags(t, tag, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// constraintTags adds the keywords for the enum, min, max and pattern tags
+// of a field of type t to the schema s, or, if s is nil, checks that there
+// are none. Like Decode, it returns an error if a tag is malformed or does
+// not apply to t.
+func constraintTags(t reflect.Type, tag fieldTag, s map[string]interface{}) error {
+	if tag.enum != nil {
+		if k := t.Kind(); s == nil || k == reflect.Struct || k == reflect.Slice || k == reflect.Map || k == reflect.Ptr {
+			return fmt.Errorf("enum tag on unsupported type %v", t)
+		}
+		enum := make([]interface{}, len(tag.enum))
+		for i, e := range tag.enum {
+			v, err := parseDefault(t, tag, e)
+			if err != nil {
+				return fmt.Errorf("bad enum value %q: %v", e, err)
+			}
+			enum[i] = v
+		}
+		s["enum"] = enum
+	}
+	for _, b := range []struct {
+		s       *string
+		name    string
+		keyword string
+	}{{tag.min, "min", "minimum"}, {tag.max, "max", "maximum"}} {
+		if b.s == nil {
+			continue
+		}
+		k := t.Kind()
+		if s == nil || k != reflect.Int && k != reflect.Int64 && k != reflect.Uint && k != reflect.Float64 {
+			return fmt.Errorf("%s tag on unsupported type %v", b.name, t)
+		}
+		if t == durationType {
+			if _, err := time.ParseDuration(*b.s); err != nil {
+				return fmt.Errorf("bad %s %q: %v", b.name, *b.s, err)
+			}
+			continue
+		}
+		v, err := parseDefault(t, fieldTag{}, *b.s)
+		if err != nil {
+			return fmt.Errorf("bad %s %q: %v", b.name, *b.s, err)
+		}
+		s[b.keyword] = v
+	}
+	if tag.pattern != nil {
+		if s == nil || t.Kind() != reflect.String {
+			return fmt.Errorf("pattern tag on unsupported type %v", t)
+		}
+		if _, err := regexp.Compile(*tag.pattern); err != nil {
+			return fmt.Errorf("bad pattern %q: %v", *tag.pattern, err)
+		}
+		s["pattern"] = *tag.pattern
+	}
+	return nil
+}
+
+// defaultValue returns the default value of a field of type ft with the
+// options tag as a JSON value, or nil if Decode does not apply defaults
+// to fields of type ft.
+func defaultValue(ft reflect.Type, tag fieldTag) (interface{}, error) {
+	if ft.Kind() != reflect.Slice {
+		if ft == objType || ft.Kind() == reflect.Ptr || ft.Kind() == reflect.Struct && ft != timeType {
+			return nil, nil
+		}
+		return parseDefault(ft, tag, *tag.def)
+	}
+	et := ft.Elem()
+	if et == objType || et.Kind() == reflect.Struct && et != timeType {
+		return nil, nil
+	}
+	l := []interface{}{}
+	for _, s := range splitDefault(*tag.def) {
+		v, err := parseDefault(et, fieldTag{}, s)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, v)
+	}
+	return l, nil
+}
+
+// parseDefault converts s, a default or constraint tag value of a field
+// of type t read with the options tag, to the JSON value it stands for.
+func parseDefault(t reflect.Type, tag fieldTag, s string) (interface{}, error) {
+	switch {
+	case t == durationType:
+		_, err := time.ParseDuration(s)
+		return s, err
+	case t == timeType:
+		_, err := time.Parse(tag.layout, s)
+		return s, err
+	case tag.size:
+		_, err := ParseSize(s)
+		return s, err
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return json.Number(strconv.FormatInt(n, 10)), err
+	case reflect.Uint:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return json.Number(strconv.FormatUint(n, 10)), err
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			err = fmt.Errorf("%s is not a JSON number", s)
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), err
+	}
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
@@ -0,0 +1,158 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type genServer struct {
+	Host string     `jsoncfg:"host,required" description:"The host name or address."`
+	Port int        `jsoncfg:"port" default:"80" min:"1" max:"65535"`
+	Next *genServer `jsoncfg:"next"`
+}
+
+type genConfig struct {
+	Name     string        `jsoncfg:"name,required" pattern:"^[a-z]+$"`
+	Listen   genServer     `jsoncfg:"listen,required"`
+	LogLevel string        `jsoncfg:"log_level" enum:"debug,info,warn" default:"info"`
+	Timeout  time.Duration `jsoncfg:"timeout" default:"30s" max:"1m"`
+	MaxBody  int64         `jsoncfg:"max_body,size" default:"1MiB"`
+	Debug    bool          `jsoncfg:"debug"`
+	Weights  []float64     `jsoncfg:"weights" default:"1, 2.5"`
+	Backends []genServer   `jsoncfg:"backends"`
+	Extra    Obj           `jsoncfg:"extra"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	data, err := GenerateSchema(&genConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	props := doc["properties"].(map[string]interface{})
+	server := doc["$defs"].(map[string]interface{})["genServer"].(map[string]interface{})
+	for _, tt := range []struct {
+		got, want interface{}
+	}{
+		{doc["required"], []interface{}{"name", "listen"}},
+		{doc["additionalProperties"], false},
+		{doc["patternProperties"], map[string]interface{}{"^_": true}},
+		{props["log_level"], map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "string", "enum": []interface{}{"debug", "info", "warn"}},
+				map[string]interface{}{"$ref": "#/$defs/_env"},
+				map[string]interface{}{"$ref": "#/$defs/_secret"},
+			},
+			"default": "info",
+		}},
+		{props["weights"], map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"anyOf": []interface{}{
+					map[string]interface{}{"type": "number"},
+					map[string]interface{}{"$ref": "#/$defs/_secret"},
+				},
+			},
+			"default": []interface{}{1.0, 2.5},
+		}},
+		{props["listen"], map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"$ref": "#/$defs/genServer"},
+				map[string]interface{}{"$ref": "#/$defs/_fileobj"},
+			},
+		}},
+		{server["required"], []interface{}{"host"}},
+		{server["properties"].(map[string]interface{})["port"], map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 65535.0},
+				map[string]interface{}{"$ref": "#/$defs/_secret"},
+			},
+			"default": 80.0,
+		}},
+		{server["properties"].(map[string]interface{})["host"].(map[string]interface{})["description"], "The host name or address."},
+	} {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("got  %v\nwant %v", tt.got, tt.want)
+		}
+	}
+
+	s, err := ParseSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile("testdata/schemagen.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(string(raw)))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(v); err != nil {
+		t.Errorf("Validate of the unevaluated config = %v", err)
+	}
+	v.(map[string]interface{})["listen"] = map[string]interface{}{"host": "h", "port": []interface{}{"_env", "${PORT}"}}
+	if err := s.Validate(v); err == nil {
+		t.Error("Validate accepted an _env expression for an integer")
+	}
+
+	var c ConfigParser
+	if _, err := c.ReadFile("testdata/schemagen.json"); err != nil {
+		t.Fatal(err)
+	}
+	var merr MultiError
+	if err := c.ValidateSchema(s); !errors.As(err, &merr) {
+		t.Fatalf("ValidateSchema = %v; want a MultiError", err)
+	}
+	want := []string{
+		`testdata/schemagen_backend.json:3:11: Config value at "/backends/1/port" must be at most 65535`,
+		`testdata/schemagen_backend.json:4:10: Config value at "/backends/1/tls" is not an allowed property`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), merr)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+}
+
+func TestGenerateSchemaErrors(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{42, "jsoncfgo: GenerateSchema requires a struct or a pointer to a struct, not int"},
+		{&struct {
+			M map[string]int `jsoncfg:"m"`
+		}{}, "jsoncfgo: field .M: unsupported type map[string]int"},
+		{&struct {
+			N int `jsoncfg:"n" default:"x"`
+		}{}, `jsoncfgo: field .N: bad default "x": strconv.ParseInt: parsing "x": invalid syntax`},
+		{&struct {
+			N int `jsoncfg:"n" pattern:"^1"`
+		}{}, "jsoncfgo: field .N: pattern tag on unsupported type int"},
+		{&struct {
+			L []string `jsoncfg:"l" min:"1"`
+		}{}, "jsoncfgo: field .L: min tag on unsupported type []string"},
+		{&struct {
+			D dbConfig `jsoncfg:"d" enum:"a"`
+		}{}, "jsoncfgo: field .D: enum tag on unsupported type jsoncfgo.dbConfig"},
+	} {
+		_, err := GenerateSchema(tt.v)
+		if g := errString(err); g != tt.want {
+			t.Errorf("GenerateSchema(%T) = %s\nwant %s", tt.v, g, tt.want)
+		}
+	}
+}
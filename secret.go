@@ -0,0 +1,236 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// A Secret is a config value marked with the _secret expression, such as a
+// password read from the environment:
+//
+//	"password": ["_secret", ["_env", "${DB_PASSWORD}"]]
+//
+// The accessors of Obj return the value a Secret holds like any other
+// value, but the Secret is redacted wherever it would be printed: by fmt,
+// log/slog and encoding/json, by WriteDiff and WriteDiffJSON, and in the
+// errors recorded by the accessors. A Secret holds a string, number or
+// boolean; marking an object or list secret marks each of the values it
+// contains.
+type Secret struct {
+	v interface{}
+}
+
+// secretMask replaces secret values wherever they are printed.
+const secretMask = "***"
+
+// Value returns the value held by s.
+func (s Secret) Value() interface{} { return s.v }
+
+func (s Secret) String() string { return secretMask }
+
+func (s Secret) GoString() string { return secretMask }
+
+// Format redacts s for every verb, quoting it for %q.
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		io.WriteString(f, strconv.Quote(secretMask))
+		return
+	}
+	io.WriteString(f, secretMask)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(secretMask)
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(secretMask)
+}
+
+// reveal returns the value held by v if it is a Secret, and v otherwise.
+func reveal(v interface{}) interface{} {
+	if s, ok := v.(Secret); ok {
+		return s.v
+	}
+	return v
+}
+
+// revealList returns l, or a copy of l with its secrets revealed if it
+// holds any.
+func revealList(l []interface{}) []interface{} {
+	for i, v := range l {
+		if _, ok := v.(Secret); !ok {
+			continue
+		}
+		rl := append([]interface{}(nil), l[:i]...)
+		for _, v := range l[i:] {
+			rl = append(rl, reveal(v))
+		}
+		return rl
+	}
+	return l
+}
+
+// markSecret returns v with its strings, numbers and booleans replaced by
+// Secrets.
+func markSecret(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil, Secret:
+		return v
+	case map[string]interface{}:
+		for k, ev := range t {
+			t[k] = markSecret(ev)
+		}
+		return t
+	case []interface{}:
+		for i, ev := range t {
+			t[i] = markSecret(ev)
+		}
+		return t
+	}
+	return Secret{v}
+}
+
+// holdsSecret reports whether v is a Secret or a list or object holding
+// one, at any depth.
+func holdsSecret(v interface{}) bool {
+	switch t := v.(type) {
+	case Secret:
+		return true
+	case map[string]interface{}:
+		for _, ev := range t {
+			if holdsSecret(ev) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, ev := range t {
+			if holdsSecret(ev) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ["_secret", value]
+func (c *ConfigParser) expandSecret(v []interface{}) (interface{}, error) {
+	if len(v) != 1 {
+		return nil, fmt.Errorf("_secret expansion expected 1 arg, got %d", len(v))
+	}
+	val, err := c.EvalValue(v[0])
+	if err != nil {
+		return nil, err
+	}
+	return markSecret(val), nil
+}
+
+// secretAt returns the Secret at key in jc, at index in the list at key if
+// index is not -1, if there is one. The key may also name an entry of an
+// object of jc, as "key.entry", as in the errors of the map accessors.
+func (jc Obj) secretAt(key string, index int) (Secret, bool) {
+	v, ok := jc.m[key]
+	if !ok {
+		if i := strings.LastIndexByte(key, '.'); i >= 0 {
+			m, _ := jc.m[key[:i]].(map[string]interface{})
+			v = m[key[i+1:]]
+		}
+	}
+	if l, ok := v.([]interface{}); ok && index >= 0 && index < len(l) {
+		v = l[index]
+	}
+	s, ok := v.(Secret)
+	return s, ok
+}
+
+// A redactedError stands for an error that shows a secret value. It has
+// the message of the first error of the original chain that does not show
+// the secret, and wraps the rest of the chain from the first error whose
+// own chain does not show it, so that errors.Is still matches errors such
+// as strconv.ErrSyntax but nothing can recover the value.
+type redactedError struct {
+	msg string
+	err error
+}
+
+// redactError returns err, or a redactedError in its place if err or an
+// error it wraps shows the value of s.
+func redactError(err error, s Secret) error {
+	secret := fmt.Sprint(s.v)
+	if err == nil || !showsSecret(err, secret) {
+		return err
+	}
+	e := &redactedError{}
+	for link := err; link != nil; link = errors.Unwrap(link) {
+		if msg := link.Error(); e.msg == "" && !containsWord(msg, secret) {
+			e.msg = msg
+		}
+		if link != err && !showsSecret(link, secret) {
+			e.err = link
+			break
+		}
+	}
+	if e.msg == "" {
+		e.msg = "bad value"
+	}
+	return e
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// showsSecret reports whether the message of err, or of an error it wraps,
+// shows secret.
+func showsSecret(err error, secret string) bool {
+	if containsWord(err.Error(), secret) {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if u := e.Unwrap(); u != nil {
+			return showsSecret(u, secret)
+		}
+	case interface{ Unwrap() []error }:
+		for _, u := range e.Unwrap() {
+			if showsSecret(u, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s contains word other than as part of a
+// longer word or number, so that a short secret such as "1" is not found
+// in "100" nor "a" in "invalid".
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	for i := 0; ; {
+		j := strings.Index(s[i:], word)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(word)
+		if !isWordByte(s, start-1) && !isWordByte(s, end) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+// isWordByte reports whether s has a letter or digit at i.
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
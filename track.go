@@ -0,0 +1,123 @@
+package jsoncfgo
+
+import (
+	"sync"
+)
+
+// A tracker records the keys of one config object read by its accessors
+// and the errors they found. It is kept outside of the object, so that
+// accessors never modify the object itself, and is safe for concurrent
+// use. The tracker of a config is created with it by ReadFile, NewObj or
+// Snapshot, and those of its nested objects and lists by the accessors
+// that return them, so that the trackers form a tree like the config.
+type tracker struct {
+	// forward, if not nil, is the tracker that receives the errors
+	// recorded here. It is set for the views created by Obj.At.
+	forward *tracker
+	// viewed, if not nil, is the tracker of the object or list holding
+	// the value seen through a view, at viewedKey, and tracks the nested
+	// objects read through the view in place of its own children.
+	viewed    *tracker
+	viewedKey string
+
+	mu       sync.Mutex
+	known    map[string]bool
+	errors   []error
+	children map[string]*tracker // by key, or index for lists
+}
+
+func newTracker() *tracker {
+	return &tracker{known: make(map[string]bool)}
+}
+
+// child returns the tracker of the value at key in the object or list
+// tracked by t, creating it if needed. The child of a nil tracker is nil.
+func (t *tracker) child(key string) *tracker {
+	if t == nil {
+		return nil
+	}
+	if t.viewed != nil {
+		return t.viewed.child(t.viewedKey)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.children[key]
+	if c == nil {
+		if t.children == nil {
+			t.children = make(map[string]*tracker)
+		}
+		c = newTracker()
+		t.children[key] = c
+	}
+	return c
+}
+
+// lookupChild returns the tracker of the value at key, or nil if no
+// accessor has read it.
+func (t *tracker) lookupChild(key string) *tracker {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.children[key]
+}
+
+func (t *tracker) noteKnownKey(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.known[key] = true
+}
+
+func (t *tracker) isKnown(key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.known[key]
+}
+
+func (t *tracker) appendError(err error) {
+	if t.forward != nil {
+		t.forward.appendError(err)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, err)
+}
+
+// errorList returns a copy of the errors recorded so far.
+func (t *tracker) errorList() []error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]error(nil), t.errors...)
+}
+
+// Snapshot returns a deep copy of jc. The accessors of an Obj never modify
+// it, so a config may be read from any number of goroutines at once as
+// long as nothing modifies it; a snapshot guarantees this even if jc
+// itself is later modified, for example by a caller that still holds it.
+// The snapshot must not be modified by its users either, and starts with
+// no known keys or errors.
+func (jc Obj) Snapshot() Obj {
+	return NewObj(snapshotValue(jc.m).(map[string]interface{}))
+}
+
+func snapshotValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case Obj:
+		return snapshotValue(t.m)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, sv := range t {
+			m[k] = snapshotValue(sv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, sv := range t {
+			l[i] = snapshotValue(sv)
+		}
+		return l
+	}
+	return v
+}
@@ -0,0 +1,122 @@
+package jsoncfgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotConcurrentReads(t *testing.T) {
+	obj, err := ReadFile("testdata/include1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := obj.Snapshot()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				two := snap.RequiredObject("two")
+				if g := two.RequiredString("key"); g != "value" {
+					t.Errorf("two.key = %q; want %q", g, "value")
+				}
+				two.OptionalInt("missing", 1)
+				snap.RequiredString("absent")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(snap.Map()) != 1 {
+		t.Error("snapshot accessors modified the snapshot")
+	}
+	if len(snap.RequiredObject("two").Map()) != 1 {
+		t.Error("snapshot accessors modified a nested object")
+	}
+	if g, e := len(snap.errorList()), 800; g != e {
+		t.Errorf("recorded %d errors; want %d", g, e)
+	}
+	if err := snap.RequiredObject("two").Validate(); err != nil {
+		t.Errorf("nested Validate = %v", err)
+	}
+}
+
+func TestSnapshotIsACopy(t *testing.T) {
+	m := map[string]interface{}{"a": "x", "list": []interface{}{"y"}, "sub": map[string]interface{}{"b": true}}
+	obj := NewObj(m)
+	obj.RequiredString("a")
+	snap := obj.Snapshot()
+	m["a"] = "changed"
+	m["list"].([]interface{})[0] = "changed"
+	m["sub"].(map[string]interface{})["b"] = false
+	if g := snap.RequiredString("a"); g != "x" {
+		t.Errorf("a = %q; want %q", g, "x")
+	}
+	if g := snap.RequiredList("list"); g[0] != "y" {
+		t.Errorf("list = %q; want [y]", g)
+	}
+	if !snap.RequiredObject("sub").RequiredBool("b") {
+		t.Error("sub.b = false; want true")
+	}
+	if err := snap.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTrackersPerObj(t *testing.T) {
+	m := map[string]interface{}{"sub": map[string]interface{}{"k": "v"}}
+	a, b := NewObj(m), NewObj(m)
+	a.RequiredObject("sub").RequiredString("missing")
+	if err := a.ValidateAll(); err == nil {
+		t.Error("expected a missing key error")
+	}
+	b.RequiredObject("sub").RequiredString("k")
+	if err := b.ValidateAll(); err != nil {
+		t.Errorf("ValidateAll of another Obj of the same map = %v", err)
+	}
+}
+
+func TestBookkeepingOutsideObj(t *testing.T) {
+	obj, err := ReadFile("testdata/include1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	two := obj.RequiredObject("two")
+	two.RequiredString("key")
+	two.RequiredInt("missing")
+	obj.RequiredBool("absent")
+	if err := obj.Validate(); err == nil {
+		t.Error("expected a missing key error")
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, e := string(b), `{"two":{"key":"value"}}`; g != e {
+		t.Errorf("marshaled config = %s; want %s", g, e)
+	}
+	if g, e := fmt.Sprint(obj), "map[two:map[key:value]]"; g != e {
+		t.Errorf("printed config = %s; want %s", g, e)
+	}
+	if err := two.Validate(); err == nil || !strings.Contains(err.Error(), `"missing"`) {
+		t.Errorf("nested Validate = %v; want a missing key error", err)
+	}
+}
+
+func TestNilObj(t *testing.T) {
+	var obj Obj
+	if g := obj.OptionalString("a", "def"); g != "def" {
+		t.Errorf("a = %q; want %q", g, "def")
+	}
+	obj.RequiredString("b")
+	if err := obj.Validate(); err != ErrNoTracker {
+		t.Errorf("Validate of the zero Obj = %v; want ErrNoTracker", err)
+	}
+	if err := obj.ValidateAll(); err != ErrNoTracker {
+		t.Errorf("ValidateAll of the zero Obj = %v; want ErrNoTracker", err)
+	}
+}
@@ -0,0 +1,245 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (jc Obj) RequiredDuration(key string) time.Duration {
+	return jc.duration(key, nil)
+}
+
+func (jc Obj) OptionalDuration(key string, def time.Duration) time.Duration {
+	return jc.duration(key, &def)
+}
+
+// Duration is an OptionalDuration and accepts an optional time.Duration
+// parameter
+func (jc Obj) Duration(key string, args ...interface{}) time.Duration {
+	var def time.Duration
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case time.Duration:
+			def = t
+		default:
+			panic(fmt.Sprintf("ERROR - Invalid argument (%v).  Must be a time.Duration.", arg))
+		}
+	}
+	return jc.duration(key, &def)
+}
+
+// duration reads a duration written as a string accepted by
+// time.ParseDuration, such as "30s" or "1h30m".
+func (jc Obj) duration(key string, def *time.Duration) time.Duration {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if def != nil {
+			return *def
+		}
+		jc.appendError(missingKey(key, "duration"))
+		return 0
+	}
+	s, ok := ei.(string)
+	if !ok {
+		jc.appendError(wrongType(key, -1, "a duration string", ei))
+		return 0
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		jc.appendError(jc.badValue(key, -1, s, "duration", durationError(s, err)))
+		return 0
+	}
+	return d
+}
+
+// durationError strips the value s that time.ParseDuration repeats in its
+// error err, since ValueError already reports it.
+func durationError(s string, err error) error {
+	q := strconv.Quote(s)
+	msg := strings.Replace(err.Error(), " in duration "+q, "", 1)
+	return errors.New(strings.Replace(msg, " "+q, "", 1))
+}
+
+func (jc Obj) RequiredTime(key string) time.Time {
+	return jc.time(key, time.RFC3339, nil)
+}
+
+func (jc Obj) OptionalTime(key string, def time.Time) time.Time {
+	return jc.time(key, time.RFC3339, &def)
+}
+
+// Time is an OptionalTime and accepts an optional time.Time parameter
+func (jc Obj) Time(key string, args ...interface{}) time.Time {
+	var def time.Time
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			def = t
+		default:
+			panic(fmt.Sprintf("ERROR - Invalid argument (%v).  Must be a time.Time.", arg))
+		}
+	}
+	return jc.time(key, time.RFC3339, &def)
+}
+
+// RequiredTimeLayout is like RequiredTime, but parses the value with the
+// given layout, as defined by time.Parse, instead of RFC 3339.
+func (jc Obj) RequiredTimeLayout(key, layout string) time.Time {
+	return jc.time(key, layout, nil)
+}
+
+// OptionalTimeLayout is like OptionalTime, but parses the value with the
+// given layout, as defined by time.Parse, instead of RFC 3339.
+func (jc Obj) OptionalTimeLayout(key, layout string, def time.Time) time.Time {
+	return jc.time(key, layout, &def)
+}
+
+func (jc Obj) time(key, layout string, def *time.Time) time.Time {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if def != nil {
+			return *def
+		}
+		jc.appendError(missingKey(key, "time"))
+		return time.Time{}
+	}
+	s, ok := ei.(string)
+	if !ok {
+		jc.appendError(wrongType(key, -1, "a time string", ei))
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		jc.appendError(jc.badValue(key, -1, s, "time", &timeError{layout, err.(*time.ParseError)}))
+		return time.Time{}
+	}
+	return t
+}
+
+// A timeError reports the time.ParseError err without the value that it
+// repeats, since ValueError already reports it.
+type timeError struct {
+	layout string
+	err    *time.ParseError
+}
+
+func (e *timeError) Error() string {
+	switch {
+	case e.err.Message == "":
+		return fmt.Sprintf("does not match layout %q at %q", e.layout, e.err.LayoutElem)
+	case strings.HasPrefix(e.err.Message, ": extra text"):
+		return fmt.Sprintf("does not match layout %q: extra text", e.layout)
+	}
+	return fmt.Sprintf("does not match layout %q%s", e.layout, e.err.Message)
+}
+
+func (e *timeError) Unwrap() error { return e.err }
+
+// RequiredSize returns the byte size at key, which may be a number of
+// bytes or a string such as "512MiB" or "1.5 GB". See ParseSize.
+func (jc Obj) RequiredSize(key string) int64 {
+	return jc.size(key, nil)
+}
+
+func (jc Obj) OptionalSize(key string, def int64) int64 {
+	return jc.size(key, &def)
+}
+
+// Size is an OptionalSize and accepts an optional int64 parameter
+func (jc Obj) Size(key string, args ...interface{}) int64 {
+	var def int64
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case int:
+			def = int64(t)
+		case int64:
+			def = t
+		default:
+			panic(fmt.Sprintf("ERROR - Invalid argument (%v).  Must be an int64.", arg))
+		}
+	}
+	return jc.size(key, &def)
+}
+
+func (jc Obj) size(key string, def *int64) int64 {
+	jc.noteKnownKey(key)
+	ei, ok := jc.lookup(key)
+	if !ok {
+		if def != nil {
+			return *def
+		}
+		jc.appendError(missingKey(key, "size"))
+		return 0
+	}
+	if s, ok := ei.(string); ok {
+		n, err := ParseSize(s)
+		if err != nil {
+			jc.appendError(jc.badValue(key, -1, s, "size", err))
+			return 0
+		}
+		return n
+	}
+	n, err := toInt64(ei, 64)
+	if err == nil && n < 0 {
+		err = errNegative
+	}
+	if err != nil {
+		jc.numberError(key, ei, "size", err)
+		return 0
+	}
+	return n
+}
+
+// sizeUnits maps the lower cased size suffixes to their number of bytes.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"eb":  1e18,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+	"eib": 1 << 60,
+}
+
+// ParseSize parses a byte size: a decimal number, possibly with a
+// fraction, optionally followed by spaces and a unit. The units are B, the
+// SI units KB, MB, GB, TB, PB and EB, powers of 1000, and the IEC units
+// KiB, MiB, GiB, TiB, PiB and EiB, powers of 1024, in any case. The size
+// must be a whole number of bytes that fits in an int64.
+func ParseSize(s string) (int64, error) {
+	t := strings.TrimSpace(s)
+	i := 0
+	for i < len(t) && (t[i] >= '0' && t[i] <= '9' || t[i] == '.') {
+		i++
+	}
+	num, unit := t[:i], strings.ToLower(strings.TrimSpace(t[i:]))
+	mult, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", strings.TrimSpace(t[i:]))
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if num == "" || !ok {
+		return 0, errNotNumber
+	}
+	r.Mul(r, new(big.Rat).SetInt64(mult))
+	if !r.IsInt() {
+		return 0, errFraction
+	}
+	if !r.Num().IsInt64() {
+		return 0, errRange
+	}
+	return r.Num().Int64(), nil
+}
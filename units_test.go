@@ -0,0 +1,132 @@
+package jsoncfgo
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnits(t *testing.T) {
+	obj, err := ReadFile("testdata/units.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, w := obj.RequiredDuration("timeout"), 90*time.Second; g != w {
+		t.Errorf("timeout = %v; want %v", g, w)
+	}
+	if g, w := obj.Duration("missing_timeout", 5*time.Second), 5*time.Second; g != w {
+		t.Errorf("missing_timeout = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredTime("start"), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !g.Equal(w) {
+		t.Errorf("start = %v; want %v", g, w)
+	}
+	if g, w := obj.RequiredTimeLayout("day", "2006-01-02"), time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC); !g.Equal(w) {
+		t.Errorf("day = %v; want %v", g, w)
+	}
+	if g := obj.OptionalTime("missing_start", time.Time{}); !g.IsZero() {
+		t.Errorf("missing_start = %v; want the zero time", g)
+	}
+	sizes := []struct {
+		key  string
+		want int64
+	}{
+		{"cache", 512 << 20},
+		{"disk", 1500000000},
+		{"plain", 4096},
+	}
+	for _, tt := range sizes {
+		if g := obj.RequiredSize(tt.key); g != tt.want {
+			t.Errorf("%s = %d; want %d", tt.key, g, tt.want)
+		}
+	}
+	if g := obj.Size("missing_size", 10); g != 10 {
+		t.Errorf("missing_size = %d; want 10", g)
+	}
+	if err := obj.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnitErrors(t *testing.T) {
+	obj, err := ReadFile("testdata/units_bad.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj.RequiredDuration("bad_timeout")
+	obj.RequiredDuration("num_timeout")
+	obj.RequiredTime("bad_start")
+	obj.RequiredTime("day")
+	obj.RequiredTime("month")
+	obj.RequiredSize("odd")
+	obj.RequiredSize("huge")
+	obj.RequiredSize("weird")
+	obj.RequiredTime("nowhere")
+	obj.Time("timeout")
+	obj.Duration("start")
+	obj.Size("cache")
+	obj.Size("disk")
+	obj.Size("plain")
+	obj.RequiredSize("timeout")
+	err = obj.Validate()
+	var merr MultiError
+	if !errors.As(err, &merr) {
+		t.Fatalf("Validate = %v; want a MultiError", err)
+	}
+	want := []string{
+		`Config key "bad_timeout" value "soon" cannot be converted to duration: time: invalid duration`,
+		`Expected config key "num_timeout" to be a duration string, not json.Number`,
+		`Config key "bad_start" value "yesterday" cannot be converted to time: does not match layout "2006-01-02T15:04:05Z07:00" at "2006"`,
+		`Config key "day" value "2026-03-15" cannot be converted to time: does not match layout "2006-01-02T15:04:05Z07:00" at "T"`,
+		`Config key "month" value "2026-13-01T00:00:00Z" cannot be converted to time: does not match layout "2006-01-02T15:04:05Z07:00": month out of range`,
+		`Config key "odd" value "0.5B" cannot be converted to size: not a whole number`,
+		`Config key "huge" value "16EiB" cannot be converted to size: out of range`,
+		`Config key "weird" value "10 furlongs" cannot be converted to size: unknown size unit "furlongs"`,
+		`Missing required config key "nowhere" (time)`,
+		`Config key "timeout" value "1m30s" cannot be converted to time: does not match layout "2006-01-02T15:04:05Z07:00" at "2006"`,
+		`Config key "start" value "2026-01-01T00:00:00Z" cannot be converted to duration: time: unknown unit "-"`,
+		`Config key "timeout" value "1m30s" cannot be converted to size: unknown size unit "m30s"`,
+	}
+	if len(merr) != len(want) {
+		t.Fatalf("got %d errors; want %d:\n%v", len(merr), len(want), err)
+	}
+	for i, w := range want {
+		if g := merr[i].Error(); g != w {
+			t.Errorf("error %d = %s\nwant %s", i, g, w)
+		}
+	}
+	var perr *time.ParseError
+	if !errors.As(merr[2], &perr) || perr.Value != "yesterday" {
+		t.Errorf("error 2 = %#v; want a wrapped *time.ParseError", merr[2])
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		err  error
+	}{
+		{"0", 0, nil},
+		{"12", 12, nil},
+		{"12B", 12, nil},
+		{"1kb", 1000, nil},
+		{"1 KiB", 1024, nil},
+		{"2.5MiB", 5 << 19, nil},
+		{"1TB", 1e12, nil},
+		{"7EiB", 7 << 60, nil},
+		{"8EiB", 0, errRange},
+		{"1.1B", 0, errFraction},
+		{"", 0, errNotNumber},
+		{"MiB", 0, errNotNumber},
+		{"1..2", 0, errNotNumber},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if got != tt.want || err != tt.err {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d, %v", tt.in, got, err, tt.want, tt.err)
+		}
+	}
+	if got, err := ParseSize("-1MB"); err == nil {
+		t.Errorf("ParseSize(%q) = %d; want an error", "-1MB", got)
+	}
+}
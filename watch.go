@@ -0,0 +1,230 @@
+package jsoncfgo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Watcher keeps a config up to date with the files it is read from.
+// It polls the root files and every file they include, and when one of
+// them changes it reads and validates the config again, publishing the
+// new config only if both succeed. Until then, Config keeps returning
+// the previous one.
+type Watcher struct {
+	// OnError is called with the error of each reload that fails while
+	// polling. If nil, the package ErrorHandler is used. It must be set
+	// before calling Start.
+	OnError func(err error)
+
+	parser   *ConfigParser
+	paths    []string
+	validate func(Obj) error
+
+	current atomic.Value // Obj
+
+	reloadMu sync.Mutex // serializes reloads, which share parser
+	stamps   map[string]fileStamp
+
+	subMu   sync.Mutex
+	subs    map[int]func(Obj)
+	nextSub int
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher reads the config files at paths with c, merging them as
+// ReadFiles does if there are several, and returns a Watcher for them.
+// If validate is not nil, it is called with each config read, including
+// the first, and a config it rejects is never published. The config
+// published is not the one validated, so it starts with no known keys or
+// errors, whatever validate read. NewWatcher returns an error if the first
+// config cannot be read or is invalid.
+//
+// The Watcher uses c for every reload, so c must not be used by anything
+// else. Changes are detected with os.Stat, so c.Open must open files of
+// the local file system.
+func NewWatcher(c *ConfigParser, validate func(Obj) error, paths ...string) (*Watcher, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("jsoncfgo: NewWatcher requires at least one config file")
+	}
+	w := &Watcher{
+		parser:   c,
+		paths:    paths,
+		validate: validate,
+		subs:     make(map[int]func(Obj)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	obj, err := w.read()
+	if err != nil {
+		return nil, err
+	}
+	w.current.Store(obj)
+	return w, nil
+}
+
+// Config returns the current config. It may be called from any goroutine.
+func (w *Watcher) Config() Obj {
+	return w.current.Load().(Obj)
+}
+
+// Subscribe arranges for fn to be called with each config published after
+// the call. fn is called from the goroutine performing the reload and
+// should not block. The returned function cancels the subscription.
+func (w *Watcher) Subscribe(fn func(Obj)) (cancel func()) {
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+	id := w.nextSub
+	w.nextSub++
+	w.subs[id] = fn
+	return func() {
+		w.subMu.Lock()
+		defer w.subMu.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Start polls the config files for changes every interval in a new
+// goroutine, until Close is called. Calls after the first have no effect.
+func (w *Watcher) Start(interval time.Duration) {
+	w.startOnce.Do(func() { go w.poll(interval) })
+}
+
+func (w *Watcher) poll(interval time.Duration) {
+	defer close(w.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			if _, err := w.Check(); err != nil {
+				if w.OnError != nil {
+					w.OnError(err)
+				} else {
+					ErrorHandler(err)
+				}
+			}
+		}
+	}
+}
+
+// Close stops the polling goroutine started by Start and waits for it to
+// exit.
+func (w *Watcher) Close() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	// If Start was never called, there is nothing to wait for, and
+	// Start must now do nothing.
+	w.startOnce.Do(func() { close(w.done) })
+	<-w.done
+	return nil
+}
+
+// Check reloads the config if any of its files changed since it was last
+// read, and reports whether a new config was published.
+func (w *Watcher) Check() (bool, error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	if !w.changed() {
+		return false, nil
+	}
+	return w.reloadLocked()
+}
+
+// Reload reads the config again whether or not its files changed, and
+// publishes it if it is valid.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	_, err := w.reloadLocked()
+	return err
+}
+
+func (w *Watcher) reloadLocked() (bool, error) {
+	obj, err := w.read()
+	if err != nil {
+		return false, err
+	}
+	w.current.Store(obj)
+	w.subMu.Lock()
+	subs := make([]func(Obj), 0, len(w.subs))
+	for _, fn := range w.subs {
+		subs = append(subs, fn)
+	}
+	w.subMu.Unlock()
+	for _, fn := range subs {
+		fn(obj)
+	}
+	return true, nil
+}
+
+// read reads and validates the config, and records the state of the files
+// it was read from, successfully or not, so that a broken config is only
+// read again once it changes.
+func (w *Watcher) read() (Obj, error) {
+	var m map[string]interface{}
+	var err error
+	if len(w.paths) == 1 {
+		m, err = w.parser.ReadFile(w.paths[0])
+	} else {
+		m, err = w.parser.ReadFiles(w.paths...)
+	}
+	w.stamps = make(map[string]fileStamp)
+	for _, f := range w.parser.Files() {
+		w.stamps[f] = stat(f)
+	}
+	for _, p := range w.paths {
+		// Watch root files that could not be opened, so that their
+		// creation is noticed.
+		if abs, err := filepath.Abs(p); err == nil {
+			if _, ok := w.stamps[abs]; !ok {
+				w.stamps[abs] = stat(abs)
+			}
+		}
+	}
+	if err != nil {
+		return Obj{}, err
+	}
+	if w.validate != nil {
+		if err := w.validate(NewObj(m)); err != nil {
+			return Obj{}, fmt.Errorf("jsoncfgo: invalid config: %w", err)
+		}
+	}
+	return NewObj(m), nil
+}
+
+func (w *Watcher) changed() bool {
+	for f, old := range w.stamps {
+		if !stat(f).same(old) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s fileStamp) same(o fileStamp) bool {
+	return s.exists == o.exists && s.size == o.size && s.modTime.Equal(o.modTime)
+}
+
+func stat(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: fi.Size(), modTime: fi.ModTime()}
+}
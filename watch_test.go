@@ -0,0 +1,125 @@
+package jsoncfgo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes contents to path, giving it a modification time
+// distinct from its previous one.
+func writeConfig(t *testing.T, path, contents string, age time.Duration) {
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.json")
+	inc := filepath.Join(dir, "inc.json")
+	writeConfig(t, inc, `{"port": 1}`, time.Hour)
+	writeConfig(t, root, fmt.Sprintf(`{"name": "a", "inc": ["_fileobj", %q]}`, inc), time.Hour)
+
+	validate := func(obj Obj) error {
+		obj.RequiredString("name")
+		obj.RequiredObject("inc").RequiredInt("port")
+		return obj.ValidateAll()
+	}
+	w, err := NewWatcher(new(ConfigParser), validate, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if g, e := w.Config().UnknownKeys(), []string{"inc", "name"}; strings.Join(g, ",") != strings.Join(e, ",") {
+		t.Errorf("unknown keys of the published config = %q; want %q", g, e)
+	}
+	var published []Obj
+	cancel := w.Subscribe(func(obj Obj) { published = append(published, obj) })
+
+	if changed, err := w.Check(); changed || err != nil {
+		t.Fatalf("Check without changes = %v, %v", changed, err)
+	}
+
+	// A change in the included file is picked up.
+	writeConfig(t, inc, `{"port": 2}`, time.Minute)
+	if changed, err := w.Check(); !changed || err != nil {
+		t.Fatalf("Check after change = %v, %v", changed, err)
+	}
+	if g := w.Config().RequiredObject("inc").RequiredInt("port"); g != 2 {
+		t.Errorf("port = %d; want 2", g)
+	}
+	if len(published) != 1 {
+		t.Fatalf("published %d configs; want 1", len(published))
+	}
+
+	// Broken and invalid configs are not published.
+	writeConfig(t, inc, `{"port": `, 2*time.Minute)
+	if changed, err := w.Check(); changed || err == nil {
+		t.Errorf("Check after syntax error = %v, %v", changed, err)
+	}
+	if changed, err := w.Check(); changed || err != nil {
+		t.Errorf("Check of unchanged broken config = %v, %v", changed, err)
+	}
+	writeConfig(t, inc, `{"port": "two"}`, 3*time.Minute)
+	if changed, err := w.Check(); changed || err == nil || !strings.Contains(err.Error(), "invalid config") {
+		t.Errorf("Check after invalid change = %v, %v", changed, err)
+	}
+	if g := w.Config().RequiredObject("inc").RequiredInt("port"); g != 2 {
+		t.Errorf("port after failed reloads = %d; want 2", g)
+	}
+
+	cancel()
+	writeConfig(t, inc, `{"port": 3}`, 4*time.Minute)
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 {
+		t.Errorf("published %d configs after cancel; want 1", len(published))
+	}
+}
+
+func TestWatcherStart(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root.json")
+	writeConfig(t, root, `{"v": 1}`, time.Hour)
+	w, err := NewWatcher(new(ConfigParser), nil, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates := make(chan Obj, 1)
+	w.Subscribe(func(obj Obj) { updates <- obj })
+	w.Start(10 * time.Millisecond)
+	defer w.Close()
+
+	writeConfig(t, root, `{"v": 2}`, time.Minute)
+	select {
+	case obj := <-updates:
+		if g := obj.RequiredInt("v"); g != 2 {
+			t.Errorf("v = %d; want 2", g)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
+
+func TestNewWatcherError(t *testing.T) {
+	if _, err := NewWatcher(new(ConfigParser), nil, "testdata/loop1.json"); err == nil {
+		t.Error("expected an error for an invalid initial config")
+	}
+	w, err := NewWatcher(new(ConfigParser), nil, "testdata/include1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+	}
+	w.Start(time.Millisecond) // no effect after Close
+}